@@ -86,8 +86,13 @@ type OPAControlPlaneConfig struct {
 	// GitCredentials is the name of a secret used by the OPA Control Plane Git integration.
 	GitCredentials []*GitCredentials `json:"gitCredentials,omitempty"`
 
+	// SSHHostKeyFingerprints is the default list of SSH host key fingerprints,
+	// e.g. SHA256:uNiVztksCsDhcc0u9e8BujQXVUpKZIDTMczCvj3tD2s, which git
+	// servers are verified against when Systems fetch policy over ssh.
+	SSHHostKeyFingerprints []string `json:"sshHostKeyFingerprints,omitempty"`
+
 	// BundleObjectStorage is the object storage configuration to use for bundles.
-	// Currently only supports aws
+	// Exactly one of the supported backends must be configured.
 	BundleObjectStorage *BundleObjectStorage `json:"bundleObjectStorage,omitempty"`
 
 	// DefaultRequirements is a list of requirements that will be added to all
@@ -99,6 +104,77 @@ type OPAControlPlaneConfig struct {
 	SystemDatasourceChanged string `json:"systemDatasourceChanged,omitempty"`
 	// LibraryDatasourceChanged is the URL to be called when a library datasource has changed.
 	LibraryDatasourceChanged string `json:"libraryDatasourceChanged,omitempty"`
+
+	// ResyncInterval is how often Systems are reconciled when nothing has
+	// changed in the cluster. On each resync the sources and bundles in OPA
+	// Control Plane are compared with the desired state and reapplied if they
+	// have drifted. Drift detection is disabled when it is not set.
+	ResyncInterval metav1.Duration `json:"resyncInterval,omitempty"`
+
+	// GarbageCollection configures periodic removal of sources and bundles in
+	// OPA Control Plane which no longer belong to a System or Library.
+	GarbageCollection *GarbageCollectionConfig `json:"garbageCollection,omitempty"`
+
+	// BundleStorageAllowlist lists the buckets which Systems may store their
+	// bundles in using spec.bundleStorage. Systems cannot override the bundle
+	// storage when the list is empty.
+	BundleStorageAllowlist []AllowedBundleStorage `json:"bundleStorageAllowlist,omitempty"`
+
+	// SourceFiles are the default glob patterns for the files included from
+	// and excluded from the git repositories of Systems and Libraries. Only
+	// *.rego files, except *_test.rego files, are included when it is not set.
+	SourceFiles *FilePatterns `json:"sourceFiles,omitempty"`
+
+	// BundleExcludedFiles is the default list of glob patterns for files
+	// which are excluded from the bundles of Systems.
+	BundleExcludedFiles []string `json:"bundleExcludedFiles,omitempty"`
+
+	// DeltaBundles enables requesting delta bundles from OPA Control Plane
+	// for Systems with enableDeltaBundles set. It should only be enabled when
+	// the bundle API of the OPA Control Plane accepts the delta_bundles field.
+	DeltaBundles bool `json:"deltaBundles,omitempty"`
+}
+
+// FilePatterns contains glob patterns for the files of a git source.
+type FilePatterns struct {
+	// Included is the list of glob patterns for the files which are included.
+	Included []string `json:"included,omitempty"`
+
+	// Excluded is the list of glob patterns for the files which are excluded.
+	Excluded []string `json:"excluded,omitempty"`
+}
+
+// GarbageCollectionConfig contains configuration for garbage collection of
+// orphaned sources and bundles in OPA Control Plane.
+type GarbageCollectionConfig struct {
+	// Interval is how often garbage collection runs. Garbage collection is
+	// disabled when it is not set.
+	Interval metav1.Duration `json:"interval,omitempty"`
+
+	// DryRun makes garbage collection only report orphaned sources and bundles
+	// instead of deleting them.
+	DryRun bool `json:"dryRun,omitempty"`
+}
+
+// AllowedBundleStorage is a bucket which Systems may store their bundles in.
+type AllowedBundleStorage struct {
+	// Bucket is the name of the bucket.
+	Bucket string `json:"bucket"`
+
+	// Namespaces is a list of glob patterns for the namespaces of the Systems
+	// which may use the bucket. Systems in all namespaces may use the bucket
+	// when it is empty.
+	Namespaces []string `json:"namespaces,omitempty"`
+
+	// Credentials is the list of secrets in OPA Control Plane which Systems may
+	// use to write to the bucket.
+	Credentials []string `json:"credentials,omitempty"`
+
+	// BundleServerURL is the URL OPAs download bundles in the bucket from. For
+	// s3 it defaults to the OPA bundle server URL joined with the bucket name.
+	// For gcp and azure the bucket is part of the bundle resource, and it
+	// defaults to the OPA bundle server URL.
+	BundleServerURL string `json:"bundleServerUrl,omitempty"`
 }
 
 // NamespaceSelector defines criteria for only accepting MatchPatterns namespaces for reconciliation.
@@ -109,7 +185,10 @@ type NamespaceSelector struct {
 
 // BundleObjectStorage defines the structure for object storage configuration used by bundles
 type BundleObjectStorage struct {
-	S3 *S3ObjectStorage `json:"s3,omitempty" yaml:"s3,omitempty"`
+	S3         *S3ObjectStorage         `json:"s3,omitempty" yaml:"s3,omitempty"`
+	GCP        *GCPObjectStorage        `json:"gcp,omitempty" yaml:"gcp,omitempty"`
+	Azure      *AzureObjectStorage      `json:"azure,omitempty" yaml:"azure,omitempty"`
+	FileSystem *FileSystemObjectStorage `json:"fileSystem,omitempty" yaml:"fileSystem,omitempty"`
 }
 
 // S3ObjectStorage defines the structure for S3 object storage configuration.
@@ -120,14 +199,47 @@ type S3ObjectStorage struct {
 	OCPConfigSecretName string `json:"ocpConfigSecretName"`
 }
 
+// GCPObjectStorage defines the structure for Google Cloud Storage configuration.
+// OPAs authenticate to the bucket with an OAuth2 access token from the GCP
+// metadata server.
+type GCPObjectStorage struct {
+	Project             string `json:"project"`
+	Bucket              string `json:"bucket"`
+	OCPConfigSecretName string `json:"ocpConfigSecretName"`
+}
+
+// AzureObjectStorage defines the structure for Azure Blob Storage configuration.
+// OPAs authenticate to the container with an Azure managed identity.
+type AzureObjectStorage struct {
+	AccountURL          string `json:"accountUrl"`
+	Container           string `json:"container"`
+	OCPConfigSecretName string `json:"ocpConfigSecretName"`
+
+	// ManagedIdentityClientID is the client ID of the user assigned managed
+	// identity OPAs use. The system assigned identity is used when it is empty.
+	ManagedIdentityClientID string `json:"managedIdentityClientId,omitempty"`
+}
+
+// FileSystemObjectStorage defines the structure for storing bundles on a
+// filesystem which is shared between OCP and the OPAs.
+type FileSystemObjectStorage struct {
+	Path string `json:"path"`
+}
+
 // GitCredentials contains configuration for git credentials used by the OPA Control Plane.
 type GitCredentials struct {
 	ID string `json:"id"`
 
 	// RepoPrefix specifies a repo URL prefix. eg. if RepoPrefix is set to
 	// `https://github.com/bankdata`, then this credentials would apply for any
-	// repository under the bankdata github org.
+	// repository under the bankdata github org. When several credentials match
+	// a repository, the credentials with the longest RepoPrefix are used.
 	RepoPrefix string `json:"repoPrefix"`
+
+	// Namespaces is a list of glob patterns for the namespaces of the Systems
+	// which may use the credentials. Systems in all namespaces may use the
+	// credentials when it is empty.
+	Namespaces []string `json:"namespaces,omitempty"`
 }
 
 // OPAConfig contains default configuration for generated OPA config.
@@ -138,6 +250,15 @@ type OPAConfig struct {
 	PersistBundleDirectory string             `json:"persist_bundle_directory,omitempty" yaml:"persist_bundle_directory,omitempty"` //nolint:lll
 	BundleServer           *OPABundleServer   `json:"bundleServer,omitempty" yaml:"bundleServer,omitempty"`
 	DecisionAPIConfig      *DecisionAPIConfig `json:"decisionAPIConfig,omitempty" yaml:"decisionAPIConfig,omitempty"`
+	DeltaBundlePolling     *BundlePolling     `json:"deltaBundlePolling,omitempty" yaml:"deltaBundlePolling,omitempty"`
+}
+
+// BundlePolling contains configuration for how often OPA polls for bundle
+// updates. It is used for Systems which have delta bundles enabled.
+type BundlePolling struct {
+	MinDelaySeconds           int `json:"minDelaySeconds,omitempty" yaml:"minDelaySeconds,omitempty"`
+	MaxDelaySeconds           int `json:"maxDelaySeconds,omitempty" yaml:"maxDelaySeconds,omitempty"`
+	LongPollingTimeoutSeconds int `json:"longPollingTimeoutSeconds,omitempty" yaml:"longPollingTimeoutSeconds,omitempty"` //nolint:lll
 }
 
 // OPABundleServer contains configuration for the OPA bundle server
@@ -24,6 +24,46 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AllowedBundleStorage) DeepCopyInto(out *AllowedBundleStorage) {
+	*out = *in
+	if in.Namespaces != nil {
+		in, out := &in.Namespaces, &out.Namespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Credentials != nil {
+		in, out := &in.Credentials, &out.Credentials
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AllowedBundleStorage.
+func (in *AllowedBundleStorage) DeepCopy() *AllowedBundleStorage {
+	if in == nil {
+		return nil
+	}
+	out := new(AllowedBundleStorage)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AzureObjectStorage) DeepCopyInto(out *AzureObjectStorage) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AzureObjectStorage.
+func (in *AzureObjectStorage) DeepCopy() *AzureObjectStorage {
+	if in == nil {
+		return nil
+	}
+	out := new(AzureObjectStorage)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *BundleObjectStorage) DeepCopyInto(out *BundleObjectStorage) {
 	*out = *in
@@ -32,6 +72,21 @@ func (in *BundleObjectStorage) DeepCopyInto(out *BundleObjectStorage) {
 		*out = new(S3ObjectStorage)
 		**out = **in
 	}
+	if in.GCP != nil {
+		in, out := &in.GCP, &out.GCP
+		*out = new(GCPObjectStorage)
+		**out = **in
+	}
+	if in.Azure != nil {
+		in, out := &in.Azure, &out.Azure
+		*out = new(AzureObjectStorage)
+		**out = **in
+	}
+	if in.FileSystem != nil {
+		in, out := &in.FileSystem, &out.FileSystem
+		*out = new(FileSystemObjectStorage)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BundleObjectStorage.
@@ -44,6 +99,21 @@ func (in *BundleObjectStorage) DeepCopy() *BundleObjectStorage {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BundlePolling) DeepCopyInto(out *BundlePolling) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BundlePolling.
+func (in *BundlePolling) DeepCopy() *BundlePolling {
+	if in == nil {
+		return nil
+	}
+	out := new(BundlePolling)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *DecisionAPIConfig) DeepCopyInto(out *DecisionAPIConfig) {
 	*out = *in
@@ -91,9 +161,85 @@ func (in *DecisionLogReporting) DeepCopy() *DecisionLogReporting {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FilePatterns) DeepCopyInto(out *FilePatterns) {
+	*out = *in
+	if in.Included != nil {
+		in, out := &in.Included, &out.Included
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Excluded != nil {
+		in, out := &in.Excluded, &out.Excluded
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FilePatterns.
+func (in *FilePatterns) DeepCopy() *FilePatterns {
+	if in == nil {
+		return nil
+	}
+	out := new(FilePatterns)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FileSystemObjectStorage) DeepCopyInto(out *FileSystemObjectStorage) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FileSystemObjectStorage.
+func (in *FileSystemObjectStorage) DeepCopy() *FileSystemObjectStorage {
+	if in == nil {
+		return nil
+	}
+	out := new(FileSystemObjectStorage)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GCPObjectStorage) DeepCopyInto(out *GCPObjectStorage) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GCPObjectStorage.
+func (in *GCPObjectStorage) DeepCopy() *GCPObjectStorage {
+	if in == nil {
+		return nil
+	}
+	out := new(GCPObjectStorage)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GarbageCollectionConfig) DeepCopyInto(out *GarbageCollectionConfig) {
+	*out = *in
+	out.Interval = in.Interval
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GarbageCollectionConfig.
+func (in *GarbageCollectionConfig) DeepCopy() *GarbageCollectionConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(GarbageCollectionConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *GitCredentials) DeepCopyInto(out *GitCredentials) {
 	*out = *in
+	if in.Namespaces != nil {
+		in, out := &in.Namespaces, &out.Namespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitCredentials.
@@ -230,6 +376,11 @@ func (in *OPAConfig) DeepCopyInto(out *OPAConfig) {
 		*out = new(DecisionAPIConfig)
 		**out = **in
 	}
+	if in.DeltaBundlePolling != nil {
+		in, out := &in.DeltaBundlePolling, &out.DeltaBundlePolling
+		*out = new(BundlePolling)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OPAConfig.
@@ -252,10 +403,15 @@ func (in *OPAControlPlaneConfig) DeepCopyInto(out *OPAControlPlaneConfig) {
 			if (*in)[i] != nil {
 				in, out := &(*in)[i], &(*out)[i]
 				*out = new(GitCredentials)
-				**out = **in
+				(*in).DeepCopyInto(*out)
 			}
 		}
 	}
+	if in.SSHHostKeyFingerprints != nil {
+		in, out := &in.SSHHostKeyFingerprints, &out.SSHHostKeyFingerprints
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	if in.BundleObjectStorage != nil {
 		in, out := &in.BundleObjectStorage, &out.BundleObjectStorage
 		*out = new(BundleObjectStorage)
@@ -266,6 +422,29 @@ func (in *OPAControlPlaneConfig) DeepCopyInto(out *OPAControlPlaneConfig) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	out.ResyncInterval = in.ResyncInterval
+	if in.GarbageCollection != nil {
+		in, out := &in.GarbageCollection, &out.GarbageCollection
+		*out = new(GarbageCollectionConfig)
+		**out = **in
+	}
+	if in.BundleStorageAllowlist != nil {
+		in, out := &in.BundleStorageAllowlist, &out.BundleStorageAllowlist
+		*out = make([]AllowedBundleStorage, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.SourceFiles != nil {
+		in, out := &in.SourceFiles, &out.SourceFiles
+		*out = new(FilePatterns)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.BundleExcludedFiles != nil {
+		in, out := &in.BundleExcludedFiles, &out.BundleExcludedFiles
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OPAControlPlaneConfig.
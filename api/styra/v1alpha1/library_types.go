@@ -17,6 +17,8 @@ limitations under the License.
 package v1alpha1
 
 import (
+	"time"
+
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 )
@@ -30,6 +32,10 @@ type LibrarySpec struct {
 	// Description is the description of the Library
 	Description string `json:"description"`
 
+	// DeletionProtection disables deletion of the backing OPA Control Plane
+	// source when the Library resource is deleted.
+	DeletionProtection *bool `json:"deletionProtection,omitempty"`
+
 	// Subjects is the list of subjects which should have access to the system.
 	Subjects []LibrarySubject `json:"subjects,omitempty"`
 
@@ -96,8 +102,20 @@ type GitRepo struct {
 	// over `Reference` if both are specified.
 	Commit string `json:"commit,omitempty"`
 
-	// URL is the URL of the git repo.
+	// URL is the URL of the git repo. Both http(s) URLs and ssh URLs, e.g.
+	// ssh://git@github.com/org/repo.git or git@github.com:org/repo.git, are
+	// supported.
 	URL string `json:"url"`
+
+	// IncludedFiles is the list of glob patterns for the files in the git repo
+	// which are included in the source. The default from the controller
+	// configuration is used when it is not set.
+	IncludedFiles []string `json:"includedFiles,omitempty"`
+
+	// ExcludedFiles is the list of glob patterns for the files in the git repo
+	// which are excluded from the source. The default from the controller
+	// configuration is used when it is not set.
+	ExcludedFiles []string `json:"excludedFiles,omitempty"`
 }
 
 // LibrarySecretRef defines how to access a k8s secret for the library.
@@ -110,13 +128,119 @@ type LibrarySecretRef struct {
 
 // LibraryStatus defines the observed state of Library
 type LibraryStatus struct {
-	// INSERT ADDITIONAL STATUS FIELD - define observed state of cluster
-	// Important: Run "make" to regenerate code after modifying this file
+	// Ready is true when the library is created and in sync.
+	Ready bool `json:"ready"`
+
+	// Phase is the current state of syncing the library.
+	//+kubebuilder:default=Pending
+	//+kubebuilder:validation:Enum=Pending;Failed;Created
+	Phase LibraryPhase `json:"phase,omitempty"`
+
+	// Failure message holds a message when Phase is Failed.
+	FailureMessage string `json:"failureMessage,omitempty"`
+
+	// ObservedGeneration is the generation of the Library which was last
+	// reconciled.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Conditions holds a list of Condition which describes the state of the
+	// Library.
+	Conditions []Condition `json:"conditions,omitempty"`
+}
+
+// LibraryPhase is a status phase of the Library.
+type LibraryPhase string
+
+const (
+	// LibraryPhasePending is a LibraryPhase used when the Library has not yet
+	// been reconciled.
+	LibraryPhasePending LibraryPhase = "Pending"
+
+	// LibraryPhaseFailed is a LibraryPhase used when the Library failed to
+	// reconcile.
+	LibraryPhaseFailed LibraryPhase = "Failed"
+
+	// LibraryPhaseCreated is a LibraryPhase used when the Library is fully
+	// reconciled.
+	LibraryPhaseCreated LibraryPhase = "Created"
+)
+
+// Condition represents a Library condition.
+type Condition struct {
+	// Type is the ConditionType of the Condition.
+	Type ConditionType `json:"type"`
+
+	// Status is the status of the Condition.
+	Status metav1.ConditionStatus `json:"status"`
+
+	// LastProbeTime is a timestamp for the last time the condition was checked.
+	LastProbeTime metav1.Time `json:"lastProbeTime,omitempty"`
+
+	// LastTransitionTime is a timestamp for the last time that the condition
+	// changed state.
+	LastTransitionTime metav1.Time `json:"lastTransitionTime,omitempty"`
 }
 
+// ConditionType is a Library Condition type.
+type ConditionType string
+
+const (
+	// ConditionTypeCredentialsResolved is a ConditionType used when git
+	// credentials for the Library's repository have been resolved.
+	ConditionTypeCredentialsResolved ConditionType = "CredentialsResolved"
+
+	// ConditionTypeDatasourcesUpdated is a ConditionType used when the sources
+	// for the Library's datasources are updated in OCP.
+	ConditionTypeDatasourcesUpdated ConditionType = "DatasourcesUpdated"
+
+	// ConditionTypeSourceUpdated is a ConditionType used when the source for
+	// the Library is updated in OCP.
+	ConditionTypeSourceUpdated ConditionType = "SourceUpdated"
+)
+
+// EventType is a type of event which can be emitted by the Library controller.
+type EventType string
+
+const (
+	// EventErrorSetFinalizer is an EventType used when the controller fails to
+	// set the finalizer on the Library resource.
+	EventErrorSetFinalizer EventType = "ErrorSetFinalizer"
+
+	// EventErrorRemovingFinalizer is an EventType used when the controller fails
+	// to remove the finalizer from the Library resource.
+	EventErrorRemovingFinalizer EventType = "ErrorRemovingFinalizer"
+
+	// EventErrorPhaseToCreated is an EventType used when the controller fails to
+	// set the phase of the Library resource to Created.
+	EventErrorPhaseToCreated EventType = "ErrorPhaseToCreated"
+
+	// EventErrorCallWebhook is an EventType used when the controller fails to
+	// call the library datasource changed webhook.
+	EventErrorCallWebhook EventType = "ErrorCallWebhook"
+
+	// EventErrorResolveCredentials is an EventType used when the controller
+	// cannot find git credentials for the Library's repository.
+	EventErrorResolveCredentials EventType = "ErrorResolveCredentials"
+
+	// EventErrorUpdateSource is an EventType used when the controller fails to
+	// update a Source in OCP.
+	EventErrorUpdateSource EventType = "ErrorUpdateSource"
+
+	// EventErrorDeleteSourceInOCP is an EventType used when the controller
+	// fails to delete a Source in OCP.
+	EventErrorDeleteSourceInOCP EventType = "ErrorDeleteSourceInOCP"
+
+	// EventErrorSourceInUse is an EventType used when the controller cannot
+	// delete the Source of the Library as bundles in OCP still require it.
+	EventErrorSourceInUse EventType = "ErrorSourceInUse"
+)
+
 //+kubebuilder:object:root=true
 //+kubebuilder:subresource:status
 //+kubebuilder:resource:scope=Cluster
+//+kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.ready`
+//+kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+//+kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
 
 // Library is the Schema for the libraries API
 type Library struct {
@@ -142,3 +266,42 @@ func init() {
 		return nil
 	})
 }
+
+// SetCondition updates the matching condition under the Library's status field.
+func (l *Library) SetCondition(conditionType ConditionType, status metav1.ConditionStatus) {
+	l.setCondition(time.Now, conditionType, status)
+}
+
+// GetCondition gets the matching condition under the Library's status field.
+func (l *Library) GetCondition(conditionType ConditionType) *metav1.ConditionStatus {
+	for _, con := range l.Status.Conditions {
+		if con.Type == conditionType {
+			return &con.Status
+		}
+	}
+	return nil
+}
+
+func (l *Library) setCondition(timeNow func() time.Time, conditionType ConditionType, status metav1.ConditionStatus) {
+	now := metav1.NewTime(timeNow())
+
+	for i, con := range l.Status.Conditions {
+		if con.Type != conditionType {
+			continue
+		}
+		if con.Status != status {
+			con.LastTransitionTime = now
+			con.Status = status
+		}
+		con.LastProbeTime = now
+		l.Status.Conditions[i] = con
+		return
+	}
+
+	l.Status.Conditions = append(l.Status.Conditions, Condition{
+		LastProbeTime:      now,
+		LastTransitionTime: now,
+		Status:             status,
+		Type:               conditionType,
+	})
+}
@@ -0,0 +1,127 @@
+/*
+Copyright (C) 2025 Bankdata (bankdata@bankdata.dk)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"time"
+
+	ginkgo "github.com/onsi/ginkgo/v2"
+	gomega "github.com/onsi/gomega"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ = ginkgo.Describe("Library", func() {
+
+	ginkgo.DescribeTable("SetCondition",
+		func(
+			conditions []Condition,
+			conditionType ConditionType,
+			status metav1.ConditionStatus,
+			expectedConditions []Condition,
+		) {
+			l := Library{
+				Status: LibraryStatus{
+					Conditions: conditions,
+				},
+			}
+			l.setCondition(func() time.Time {
+				return time.Time{}
+			}, conditionType, status)
+
+			gomega.Ω(l.Status.Conditions).To(gomega.Equal(expectedConditions))
+		},
+
+		ginkgo.Entry("Add first condition", nil,
+			ConditionTypeSourceUpdated, metav1.ConditionTrue,
+			[]Condition{
+				{
+					Type:   ConditionTypeSourceUpdated,
+					Status: metav1.ConditionTrue,
+				},
+			},
+		),
+
+		ginkgo.Entry("Add new condition",
+			[]Condition{
+				{
+					Type:   ConditionTypeSourceUpdated,
+					Status: metav1.ConditionTrue,
+				},
+			},
+			ConditionTypeCredentialsResolved, metav1.ConditionFalse,
+			[]Condition{
+				{
+					Type:   ConditionTypeSourceUpdated,
+					Status: metav1.ConditionTrue,
+				},
+				{
+					Type:   ConditionTypeCredentialsResolved,
+					Status: metav1.ConditionFalse,
+				},
+			},
+		),
+
+		ginkgo.Entry("Update status on existing condition",
+			[]Condition{
+				{
+					Type:   ConditionTypeSourceUpdated,
+					Status: metav1.ConditionTrue,
+				},
+				{
+					Type:   ConditionTypeCredentialsResolved,
+					Status: metav1.ConditionFalse,
+				},
+			},
+			ConditionTypeCredentialsResolved, metav1.ConditionTrue,
+			[]Condition{
+				{
+					Type:   ConditionTypeSourceUpdated,
+					Status: metav1.ConditionTrue,
+				},
+				{
+					Type:   ConditionTypeCredentialsResolved,
+					Status: metav1.ConditionTrue,
+				},
+			},
+		),
+	)
+
+	ginkgo.DescribeTable("GetCondition",
+		func(conditions []Condition, conditionType ConditionType, expected *metav1.ConditionStatus) {
+			l := Library{
+				Status: LibraryStatus{
+					Conditions: conditions,
+				},
+			}
+			gomega.Ω(l.GetCondition(conditionType)).To(gomega.Equal(expected))
+		},
+
+		ginkgo.Entry("no conditions", nil, ConditionTypeSourceUpdated, nil),
+
+		ginkgo.Entry("matching condition",
+			[]Condition{
+				{
+					Type:   ConditionTypeSourceUpdated,
+					Status: metav1.ConditionFalse,
+				},
+			},
+			ConditionTypeSourceUpdated,
+			func() *metav1.ConditionStatus { s := metav1.ConditionFalse; return &s }(),
+		),
+	)
+})
@@ -24,9 +24,36 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Condition) DeepCopyInto(out *Condition) {
+	*out = *in
+	in.LastProbeTime.DeepCopyInto(&out.LastProbeTime)
+	in.LastTransitionTime.DeepCopyInto(&out.LastTransitionTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Condition.
+func (in *Condition) DeepCopy() *Condition {
+	if in == nil {
+		return nil
+	}
+	out := new(Condition)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *GitRepo) DeepCopyInto(out *GitRepo) {
 	*out = *in
+	if in.IncludedFiles != nil {
+		in, out := &in.IncludedFiles, &out.IncludedFiles
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ExcludedFiles != nil {
+		in, out := &in.ExcludedFiles, &out.ExcludedFiles
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitRepo.
@@ -45,7 +72,7 @@ func (in *Library) DeepCopyInto(out *Library) {
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Library.
@@ -131,6 +158,11 @@ func (in *LibrarySecretRef) DeepCopy() *LibrarySecretRef {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *LibrarySpec) DeepCopyInto(out *LibrarySpec) {
 	*out = *in
+	if in.DeletionProtection != nil {
+		in, out := &in.DeletionProtection, &out.DeletionProtection
+		*out = new(bool)
+		**out = **in
+	}
 	if in.Subjects != nil {
 		in, out := &in.Subjects, &out.Subjects
 		*out = make([]LibrarySubject, len(*in))
@@ -161,6 +193,13 @@ func (in *LibrarySpec) DeepCopy() *LibrarySpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *LibraryStatus) DeepCopyInto(out *LibraryStatus) {
 	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LibraryStatus.
@@ -194,7 +233,7 @@ func (in *SourceControl) DeepCopyInto(out *SourceControl) {
 	if in.LibraryOrigin != nil {
 		in, out := &in.LibraryOrigin, &out.LibraryOrigin
 		*out = new(GitRepo)
-		**out = **in
+		(*in).DeepCopyInto(*out)
 	}
 }
 
@@ -43,6 +43,16 @@ type SystemSpec struct {
 	// Datasources represents a list of datasources to be mounted in the system.
 	Datasources []Datasource `json:"datasources,omitempty"`
 
+	// Requirements is a list of additional sources in OPA Control Plane, such
+	// as Libraries, which are included in the bundle of the system. A
+	// requirement takes precedence over a default requirement from the
+	// controller configuration for the same source.
+	Requirements []Requirement `json:"requirements,omitempty"`
+
+	// LibrarySelector selects Libraries by label. The sources of the selected
+	// Libraries are included in the bundle of the system.
+	LibrarySelector *metav1.LabelSelector `json:"librarySelector,omitempty"`
+
 	// DiscoveryOverrides is an OPA config which will take precedence over the
 	// configuration supplied by the OPA discovery API. Configuration set here
 	// will be merged with the configuration supplied by the discovery API.
@@ -51,9 +61,61 @@ type SystemSpec struct {
 	SourceControl *SourceControl `json:"sourceControl,omitempty"`
 	LocalPlane    *LocalPlane    `json:"localPlane,omitempty"`
 
+	// Files are policy and data files which are embedded in the source of the
+	// system, keyed by their path in the source, e.g. `policy/main.rego`.
+	// Systems with files do not need to configure source control.
+	Files map[string]string `json:"files,omitempty"`
+
+	// FilesFrom references ConfigMaps in the namespace of the system whose
+	// keys are embedded in the source of the system as files.
+	FilesFrom []FilesFromSource `json:"filesFrom,omitempty"`
+
 	// CustomOPAConfig allows the owner of a System resource to set custom features
 	// without having to extend the Controller
 	CustomOPAConfig *runtime.RawExtension `json:"customOPAConfig,omitempty"`
+
+	// BundleStorage overrides where the bundle of the system is stored. The
+	// bucket must be allowed by the bundle storage allowlist in the controller
+	// configuration.
+	BundleStorage *BundleStorage `json:"bundleStorage,omitempty"`
+
+	// BundleExcludedFiles is the list of glob patterns for files which are
+	// excluded from the bundle of the system. The default from the controller
+	// configuration is used when it is not set.
+	BundleExcludedFiles []string `json:"bundleExcludedFiles,omitempty"`
+}
+
+// FilesFromSource references a ConfigMap holding files for the source of a
+// system.
+type FilesFromSource struct {
+	// ConfigMapName is the name of the ConfigMap in the namespace of the
+	// system.
+	ConfigMapName string `json:"configMapName"`
+
+	// Path is the directory in the source which the keys of the ConfigMap are
+	// placed in. The keys are placed in the root of the source when it is not
+	// set.
+	Path string `json:"path,omitempty"`
+}
+
+// BundleStorage specifies a bucket which the bundle of a system is stored in
+// instead of the bucket from the controller configuration.
+type BundleStorage struct {
+	// Bucket is the name of the bucket the bundle is stored in.
+	Bucket string `json:"bucket"`
+
+	// KeyPrefix is prepended to the key of the bundle in the bucket.
+	KeyPrefix string `json:"keyPrefix,omitempty"`
+
+	// Region is the region of the bucket. The region from the controller
+	// configuration is used when it is not set. Another region can only be
+	// used when the allowlist entry for the bucket sets a bundle server URL.
+	Region string `json:"region,omitempty"`
+
+	// Credentials is the name of the secret in OPA Control Plane used to write
+	// the bundle to the bucket. The credentials from the controller
+	// configuration are used when it is not set.
+	Credentials string `json:"credentials,omitempty"`
 }
 
 // DiscoveryOverrides specifies system specific overrides for the configuration
@@ -208,7 +270,10 @@ type GitRepo struct {
 	// CredentialsSecretName is a reference to an existing secret which holds git
 	// credentials. This secret should have the keys `name` and `secret`. The
 	// `name` key should contain the http basic auth username and the `secret`
-	// key should contain the http basic auth password.
+	// key should contain the http basic auth password. For ssh URLs the secret
+	// should instead hold an SSH private key in the `ssh-privatekey` key, and
+	// can hold the SSH host key fingerprints of the git server in the
+	// `fingerprints` key.
 	CredentialsSecretName string `json:"credentialsSecretName,omitempty"`
 
 	// Path is the path in the git repo where the policies are located.
@@ -218,12 +283,36 @@ type GitRepo struct {
 	// `Commit` is specified.
 	Reference string `json:"reference,omitempty"`
 
-	// Commit is used to point to a specific commit SHA. This takes precedence
+	// Commit is used to point to a specific full commit SHA. This takes precedence
 	// over `Reference` if both are specified.
 	Commit string `json:"commit,omitempty"`
 
-	// URL is the URL of the git repo.
+	// URL is the URL of the git repo. Both http(s) URLs and ssh URLs, e.g.
+	// ssh://git@github.com/org/repo.git or git@github.com:org/repo.git, are
+	// supported.
 	URL string `json:"url"`
+
+	// IncludedFiles is the list of glob patterns for the files in the git repo
+	// which are included in the source. The default from the controller
+	// configuration is used when it is not set.
+	IncludedFiles []string `json:"includedFiles,omitempty"`
+
+	// ExcludedFiles is the list of glob patterns for the files in the git repo
+	// which are excluded from the source. The default from the controller
+	// configuration is used when it is not set.
+	ExcludedFiles []string `json:"excludedFiles,omitempty"`
+}
+
+// Requirement is a source in OPA Control Plane which is included in the
+// bundle of a system.
+type Requirement struct {
+	// Source is the name of the source in OPA Control Plane. For Libraries
+	// this is the name of the Library.
+	Source string `json:"source"`
+
+	// Commit pins the git repository of the source to the given full commit SHA.
+	// The source follows the reference configured on it when it is not set.
+	Commit string `json:"commit,omitempty"`
 }
 
 // Datasource represents a datasource to be mounted in the system.
@@ -233,6 +322,24 @@ type Datasource struct {
 
 	// Description is a description of the datasource
 	Description string `json:"description,omitempty"`
+
+	// Type is the type of the datasource in OPA Control Plane, e.g. http. A
+	// datasource without a type is created as an empty source, and its data
+	// must be pushed to OPA Control Plane by other means.
+	Type string `json:"type,omitempty"`
+
+	// Config is the configuration of the datasource. The supported fields
+	// depend on the type of the datasource.
+	Config *runtime.RawExtension `json:"config,omitempty"`
+
+	// TransformQuery is a Rego query which transforms the data of the
+	// datasource before it is included in the bundle.
+	TransformQuery string `json:"transformQuery,omitempty"`
+
+	// CredentialsSecretName is the name of a Secret in the namespace of the
+	// System holding the credentials used to fetch the datasource. The keys of
+	// the Secret are used as the value of the secret in OPA Control Plane.
+	CredentialsSecretName string `json:"credentialsSecretName,omitempty"`
 }
 
 // SystemStatus defines the observed state of System.
@@ -255,6 +362,42 @@ type SystemStatus struct {
 	// Conditions holds a list of Condition which describes the state of the
 	// System.
 	Conditions []Condition `json:"conditions,omitempty"`
+
+	// ObservedGeneration is the generation of the System which was last
+	// successfully reconciled.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// DatasourceSources holds the IDs of the sources in OPA Control Plane which
+	// back the datasources of the System. Sources of datasources removed from
+	// the spec are deleted. While the System is deletion protected they are
+	// kept here so they can be deleted once the protection is lifted.
+	DatasourceSources []string `json:"datasourceSources,omitempty"`
+
+	// GitCredentialsSecretID holds the ID of the secret in OPA Control Plane
+	// which the controller created from the Secret referenced by
+	// `sourceControl.origin.credentialsSecretName`. The secret is deleted when
+	// the System stops referencing a Secret or is deleted.
+	GitCredentialsSecretID string `json:"gitCredentialsSecretID,omitempty"`
+
+	// UniqueName is the name of the source and bundle of the System in OPA
+	// Control Plane.
+	UniqueName string `json:"uniqueName,omitempty"`
+
+	// BundleObjectKey is the key of the bundle of the System in the object
+	// storage OPA Control Plane writes bundles to.
+	BundleObjectKey string `json:"bundleObjectKey,omitempty"`
+
+	// OPAConfigMapName is the name of the ConfigMap holding the configuration
+	// of the OPAs of the System.
+	OPAConfigMapName string `json:"opaConfigMapName,omitempty"`
+
+	// OPASecretName is the name of the Secret holding the secrets of the OPAs
+	// of the System.
+	OPASecretName string `json:"opaSecretName,omitempty"`
+
+	// LastAppliedSpecHash is a hash of the spec of the System which was last
+	// successfully reconciled.
+	LastAppliedSpecHash string `json:"lastAppliedSpecHash,omitempty"`
 }
 
 // SystemPhase is a status phase of the System.
@@ -325,6 +468,10 @@ const (
 	// ConditionTypeOPATokenUpdated is a ConditionType used when
 	// the OPA token secret has been updated in the cluster.
 	ConditionTypeOPATokenUpdated ConditionType = "OPATokenUpdated"
+
+	// ConditionTypeCredentialsResolved is a ConditionType used when the git
+	// credentials for the System's source have been resolved.
+	ConditionTypeCredentialsResolved ConditionType = "CredentialsResolved"
 )
 
 // EventType is a type of event which can be emitted by the System controller.
@@ -404,6 +551,14 @@ const (
 	// EventErrorDeleteSourceInOCP is an EventType used when the controller fails
 	// to delete the System's Source in OCP.
 	EventErrorDeleteSourceInOCP EventType = "ErrorDeleteSourceInOCP"
+
+	// EventErrorDeleteSecretInOCP is an EventType used when the controller fails
+	// to delete the System's git credentials secret in OCP.
+	EventErrorDeleteSecretInOCP EventType = "ErrorDeleteSecretInOCP"
+
+	// EventDriftDetected is an EventType used when the controller finds that the
+	// System's Source or Bundle in OCP no longer matches the System.
+	EventDriftDetected EventType = "DriftDetected"
 )
 
 //+kubebuilder:object:root=true
@@ -489,8 +644,14 @@ func (s *System) OCPUniqueName(prefix, suffix string) string {
 	return strings.ReplaceAll(path.Join(prefix, s.Namespace, s.Name, suffix), "/", "-")
 }
 
-// GitSecretID returns the internal ID of the Git Secret used by the
-// System.
-func (s *System) GitSecretID() string {
-	return path.Join("systems", s.Status.ID, "git")
+// GitSecretID returns the ID of the secret in OCP holding the git
+// credentials of the System.
+func (s *System) GitSecretID(prefix, suffix string) string {
+	return OCPGitSecretID(s.OCPUniqueName(prefix, suffix))
+}
+
+// OCPGitSecretID returns the ID of the secret in OCP holding the git
+// credentials of the System with the given unique name in OCP.
+func OCPGitSecretID(uniqueName string) string {
+	return uniqueName + "-git"
 }
@@ -151,8 +151,10 @@ var _ = ginkgo.Describe("System", func() {
 
 	ginkgo.Describe("GitSecretID", func() {
 		ginkgo.It("creates the git secret ID", func() {
-			s := &System{Status: SystemStatus{ID: "testid"}}
-			gomega.Expect(s.GitSecretID()).To(gomega.Equal("systems/testid/git"))
+			s := &System{ObjectMeta: metav1.ObjectMeta{Name: "name", Namespace: "namespace"}}
+			gomega.Expect(s.GitSecretID("prefix", "suffix")).To(gomega.Equal("prefix-namespace-name-suffix-git"))
+			gomega.Expect(s.GitSecretID("prefix", "suffix")).
+				To(gomega.Equal(OCPGitSecretID(s.OCPUniqueName("prefix", "suffix"))))
 		})
 	})
 })
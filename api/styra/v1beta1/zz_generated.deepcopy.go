@@ -21,6 +21,7 @@ limitations under the License.
 package v1beta1
 
 import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 )
 
@@ -44,6 +45,21 @@ func (in *AllowedMapping) DeepCopy() *AllowedMapping {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BundleStorage) DeepCopyInto(out *BundleStorage) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BundleStorage.
+func (in *BundleStorage) DeepCopy() *BundleStorage {
+	if in == nil {
+		return nil
+	}
+	out := new(BundleStorage)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ColumnMapping) DeepCopyInto(out *ColumnMapping) {
 	*out = *in
@@ -79,6 +95,11 @@ func (in *Condition) DeepCopy() *Condition {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Datasource) DeepCopyInto(out *Datasource) {
 	*out = *in
+	if in.Config != nil {
+		in, out := &in.Config, &out.Config
+		*out = new(runtime.RawExtension)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Datasource.
@@ -172,9 +193,34 @@ func (in *Expected) DeepCopy() *Expected {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FilesFromSource) DeepCopyInto(out *FilesFromSource) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FilesFromSource.
+func (in *FilesFromSource) DeepCopy() *FilesFromSource {
+	if in == nil {
+		return nil
+	}
+	out := new(FilesFromSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *GitRepo) DeepCopyInto(out *GitRepo) {
 	*out = *in
+	if in.IncludedFiles != nil {
+		in, out := &in.IncludedFiles, &out.IncludedFiles
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ExcludedFiles != nil {
+		in, out := &in.ExcludedFiles, &out.ExcludedFiles
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitRepo.
@@ -247,10 +293,25 @@ func (in *ReasonMapping) DeepCopy() *ReasonMapping {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Requirement) DeepCopyInto(out *Requirement) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Requirement.
+func (in *Requirement) DeepCopy() *Requirement {
+	if in == nil {
+		return nil
+	}
+	out := new(Requirement)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SourceControl) DeepCopyInto(out *SourceControl) {
 	*out = *in
-	out.Origin = in.Origin
+	in.Origin.DeepCopyInto(&out.Origin)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SourceControl.
@@ -365,8 +426,20 @@ func (in *SystemSpec) DeepCopyInto(out *SystemSpec) {
 	if in.Datasources != nil {
 		in, out := &in.Datasources, &out.Datasources
 		*out = make([]Datasource, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Requirements != nil {
+		in, out := &in.Requirements, &out.Requirements
+		*out = make([]Requirement, len(*in))
 		copy(*out, *in)
 	}
+	if in.LibrarySelector != nil {
+		in, out := &in.LibrarySelector, &out.LibrarySelector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.DiscoveryOverrides != nil {
 		in, out := &in.DiscoveryOverrides, &out.DiscoveryOverrides
 		*out = new(DiscoveryOverrides)
@@ -375,18 +448,40 @@ func (in *SystemSpec) DeepCopyInto(out *SystemSpec) {
 	if in.SourceControl != nil {
 		in, out := &in.SourceControl, &out.SourceControl
 		*out = new(SourceControl)
-		**out = **in
+		(*in).DeepCopyInto(*out)
 	}
 	if in.LocalPlane != nil {
 		in, out := &in.LocalPlane, &out.LocalPlane
 		*out = new(LocalPlane)
 		**out = **in
 	}
+	if in.Files != nil {
+		in, out := &in.Files, &out.Files
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.FilesFrom != nil {
+		in, out := &in.FilesFrom, &out.FilesFrom
+		*out = make([]FilesFromSource, len(*in))
+		copy(*out, *in)
+	}
 	if in.CustomOPAConfig != nil {
 		in, out := &in.CustomOPAConfig, &out.CustomOPAConfig
 		*out = new(runtime.RawExtension)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.BundleStorage != nil {
+		in, out := &in.BundleStorage, &out.BundleStorage
+		*out = new(BundleStorage)
+		**out = **in
+	}
+	if in.BundleExcludedFiles != nil {
+		in, out := &in.BundleExcludedFiles, &out.BundleExcludedFiles
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SystemSpec.
@@ -409,6 +504,11 @@ func (in *SystemStatus) DeepCopyInto(out *SystemStatus) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.DatasourceSources != nil {
+		in, out := &in.DatasourceSources, &out.DatasourceSources
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SystemStatus.
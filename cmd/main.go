@@ -77,12 +77,18 @@ func main() {
 	var (
 		configFiles  config.StringSlice
 		printVersion bool
+		gcOnce       bool
+		gcDryRun     bool
 	)
 
 	flag.Var(&configFiles, "config",
 		"Config file to load. Can be specified multiple times; files are deep-merged in order. "+
 			"(default /etc/styra-controller/config.yaml)")
 	flag.BoolVar(&printVersion, "version", false, "show version information")
+	flag.BoolVar(&gcOnce, "gc-once", false,
+		"collect orphaned sources and bundles in OPA Control Plane once and exit")
+	flag.BoolVar(&gcDryRun, "gc-dry-run", false,
+		"only report orphaned sources and bundles in OPA Control Plane instead of deleting them")
 	flag.Parse()
 
 	if len(configFiles) == 0 {
@@ -147,6 +153,30 @@ func main() {
 	ocpHostURL := strings.TrimSuffix(ctrlConfig.OPAControlPlaneConfig.Address, "/")
 	opaControlPlaneClient = ocp.New(ocpHostURL, ctrlConfig.OPAControlPlaneConfig.Token)
 
+	garbageCollector := &controllers.GarbageCollector{
+		Client: mgr.GetAPIReader(),
+		OCP:    opaControlPlaneClient,
+		Config: ctrlConfig,
+		Log:    ctrl.Log.WithName("garbage-collector"),
+		DryRun: gcDryRun,
+	}
+	if gcConfig := ctrlConfig.OPAControlPlaneConfig.GarbageCollection; gcConfig != nil && gcConfig.DryRun {
+		garbageCollector.DryRun = true
+	}
+
+	if gcOnce {
+		if _, err := garbageCollector.Collect(context.Background()); err != nil {
+			log.Error(err, "garbage collection failed")
+			exit(err)
+		}
+		return
+	}
+
+	if err := mgr.Add(garbageCollector); err != nil {
+		log.Error(err, "unable to add garbage collector")
+		exit(err)
+	}
+
 	// System Controller
 	systemReadyMetric := prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
@@ -190,10 +220,24 @@ func main() {
 		exit(err)
 	}
 
+	driftDetectedMetric := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "controller_system_drift_detected_total",
+			Help: "Number of times a system source or bundle in OCP was found to have drifted",
+		}, []string{"system_name", "namespace", "resource"},
+	)
+
+	if err := metrics.Registry.Register(driftDetectedMetric); err != nil {
+		err := errors.Wrap(err, "could not register driftDetectedMetric")
+		log.Error(err, err.Error())
+		exit(err)
+	}
+
 	systemMetrics := &controllers.SystemReconcilerMetrics{
 		ControllerSystemStatusReady: systemReadyMetric,
 		ReconcileSegmentTime:        reconcileSegmentTimeMetric,
 		ReconcileTime:               reconcileTimeMetric,
+		DriftDetected:               driftDetectedMetric,
 	}
 
 	r1 := &controllers.SystemReconciler{
@@ -222,16 +266,67 @@ func main() {
 	}
 
 	if !ctrlConfig.DisableCRDWebhooks {
-		if err = webhookstyrav1beta1.SetupSystemWebhookWithManager(mgr); err != nil {
+		if err = webhookstyrav1beta1.SetupSystemWebhookWithManager(mgr, ctrlConfig); err != nil {
 			log.Error(err, "unable to create webhook", "webhook", "System")
 			os.Exit(1)
 		}
 	}
 
+	// Library Controller
+	libraryReadyMetric := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "controller_library_status_ready",
+			Help: "Show if a library is in status ready",
+		},
+		[]string{"library_name", "library_id"},
+	)
+
+	if err := metrics.Registry.Register(libraryReadyMetric); err != nil {
+		err := errors.Wrap(err, "could not register controller_library_status_ready metric")
+		log.Error(err, err.Error())
+		exit(err)
+	}
+
+	libraryReconcileSegmentTimeMetric := prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "controller_library_reconcile_segment_seconds",
+			Help:    "Time taken to perform one segment of reconciling a library",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"segment"},
+	)
+
+	if err := metrics.Registry.Register(libraryReconcileSegmentTimeMetric); err != nil {
+		err := errors.Wrap(err, "could not register libraryReconcileSegmentTimeMetric")
+		log.Error(err, err.Error())
+		exit(err)
+	}
+
+	libraryReconcileTimeMetric := prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "controller_library_reconcile_seconds",
+			Help:    "Time taken to reconcile a library",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"result"},
+	)
+
+	if err := metrics.Registry.Register(libraryReconcileTimeMetric); err != nil {
+		err := errors.Wrap(err, "could not register libraryReconcileTimeMetric")
+		log.Error(err, err.Error())
+		exit(err)
+	}
+
+	libraryMetrics := &controllers.LibraryReconcilerMetrics{
+		ControllerLibraryStatusReady: libraryReadyMetric,
+		ReconcileSegmentTime:         libraryReconcileSegmentTimeMetric,
+		ReconcileTime:                libraryReconcileTimeMetric,
+	}
+
 	libraryReconciler := &controllers.LibraryReconciler{
-		Client: mgr.GetClient(),
-		Scheme: mgr.GetScheme(),
-		Config: ctrlConfig,
+		Client:   mgr.GetClient(),
+		Scheme:   mgr.GetScheme(),
+		Config:   ctrlConfig,
+		Recorder: mgr.GetEventRecorder("library-controller"),
+		Metrics:  libraryMetrics,
 	}
 
 	libraryReconciler.OCP = opaControlPlaneClient
@@ -246,7 +341,7 @@ func main() {
 	}
 
 	if !ctrlConfig.DisableCRDWebhooks {
-		if err = webhookstyrav1alpha1.SetupLibraryWebhookWithManager(mgr); err != nil {
+		if err = webhookstyrav1alpha1.SetupLibraryWebhookWithManager(mgr, ctrlConfig); err != nil {
 			log.Error(err, "unable to create webhook", "webhook", "Library")
 			os.Exit(1)
 		}
@@ -0,0 +1,141 @@
+/*
+Copyright (C) 2025 Bankdata (bankdata@bankdata.dk)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package styra
+
+import (
+	"context"
+	"slices"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	configv2alpha2 "github.com/bankdata/styra-controller/api/config/v2alpha2"
+	styrav1alpha1 "github.com/bankdata/styra-controller/api/styra/v1alpha1"
+	"github.com/bankdata/styra-controller/api/styra/v1beta1"
+	"github.com/bankdata/styra-controller/internal/labels"
+)
+
+// datasourceSourcesInUse returns the IDs of the datasource sources referenced
+// by the Systems and Libraries of the controller class, except the resource
+// with the given UID. Datasource source IDs are derived from the datasource
+// path, so resources declaring the same datasource share a source in OCP,
+// which must be kept until its last consumer is gone. Resources which are
+// being deleted only count as consumers if they are deletion protected.
+func datasourceSourcesInUse(
+	ctx context.Context,
+	c client.Reader,
+	config *configv2alpha2.ProjectConfig,
+	exclude types.UID,
+) (sets.Set[string], error) {
+	inUse := sets.New[string]()
+
+	var systems v1beta1.SystemList
+	if err := c.List(ctx, &systems); err != nil {
+		return nil, errors.Wrap(err, "could not list Systems")
+	}
+	for i := range systems.Items {
+		system := &systems.Items[i]
+		if system.UID == exclude || !labels.ControllerClassMatches(system, config.ControllerClass) {
+			continue
+		}
+		if !system.DeletionTimestamp.IsZero() &&
+			!deletionProtected(system.Spec.DeletionProtection, config.DeletionProtectionDefault) {
+			continue
+		}
+		inUse.Insert(system.Status.DatasourceSources...)
+		for _, datasource := range system.Spec.Datasources {
+			inUse.Insert(datasourceSourceID(datasource.Path))
+		}
+	}
+
+	var libraries styrav1alpha1.LibraryList
+	if err := c.List(ctx, &libraries); err != nil {
+		return nil, errors.Wrap(err, "could not list Libraries")
+	}
+	for i := range libraries.Items {
+		library := &libraries.Items[i]
+		if library.UID == exclude || !labels.ControllerClassMatches(library, config.ControllerClass) {
+			continue
+		}
+		if !library.DeletionTimestamp.IsZero() &&
+			!deletionProtected(library.Spec.DeletionProtection, config.DeletionProtectionDefault) {
+			continue
+		}
+		for _, datasource := range library.Spec.Datasources {
+			inUse.Insert(datasourceSourceID(datasource.Path))
+		}
+	}
+
+	return inUse, nil
+}
+
+// typedDatasourceOwner returns the System which owns the typed datasource
+// source with the given ID. Typed datasources configure their source and
+// credentials in OCP, so only one System may declare a typed datasource with a
+// given path. The System which has declared it the longest owns it. Systems
+// which are being deleted do not own datasources.
+func typedDatasourceOwner(
+	ctx context.Context,
+	c client.Reader,
+	config *configv2alpha2.ProjectConfig,
+	id string,
+) (*v1beta1.System, error) {
+	var systems v1beta1.SystemList
+	if err := c.List(ctx, &systems); err != nil {
+		return nil, errors.Wrap(err, "could not list Systems")
+	}
+
+	var owner *v1beta1.System
+	for i := range systems.Items {
+		system := &systems.Items[i]
+		if !system.DeletionTimestamp.IsZero() || !labels.ControllerClassMatches(system, config.ControllerClass) {
+			continue
+		}
+		if !slices.ContainsFunc(system.Spec.Datasources, func(datasource v1beta1.Datasource) bool {
+			return datasource.Type != "" && datasourceSourceID(datasource.Path) == id
+		}) {
+			continue
+		}
+		if owner == nil || ownsBefore(system, owner) {
+			owner = system
+		}
+	}
+	return owner, nil
+}
+
+// ownsBefore reports whether System a was created before System b. Systems
+// created at the same time are ordered by namespace and name.
+func ownsBefore(a, b *v1beta1.System) bool {
+	if !a.CreationTimestamp.Equal(&b.CreationTimestamp) {
+		return a.CreationTimestamp.Before(&b.CreationTimestamp)
+	}
+	if a.Namespace != b.Namespace {
+		return a.Namespace < b.Namespace
+	}
+	return a.Name < b.Name
+}
+
+// deletionProtected returns the deletion protection of a resource, falling
+// back to the default when the resource does not set it.
+func deletionProtected(deletionProtection *bool, deletionProtectionDefault bool) bool {
+	if deletionProtection != nil {
+		return *deletionProtection
+	}
+	return deletionProtectionDefault
+}
@@ -0,0 +1,88 @@
+/*
+Copyright (C) 2025 Bankdata (bankdata@bankdata.dk)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package styra
+
+import (
+	"context"
+
+	ginkgo "github.com/onsi/ginkgo/v2"
+	gomega "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	configv2alpha2 "github.com/bankdata/styra-controller/api/config/v2alpha2"
+	styrav1alpha1 "github.com/bankdata/styra-controller/api/styra/v1alpha1"
+	"github.com/bankdata/styra-controller/api/styra/v1beta1"
+	"github.com/bankdata/styra-controller/pkg/ptr"
+)
+
+var _ = ginkgo.Describe("datasourceSourcesInUse", func() {
+	ginkgo.It("returns the datasource sources of the other consumers", func() {
+		scheme := runtime.NewScheme()
+		gomega.Expect(v1beta1.AddToScheme(scheme)).To(gomega.Succeed())
+		gomega.Expect(styrav1alpha1.AddToScheme(scheme)).To(gomega.Succeed())
+
+		now := metav1.Now()
+		system := func(name string, paths ...string) *v1beta1.System {
+			s := &v1beta1.System{
+				ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", UID: types.UID(name)},
+				Status:     v1beta1.SystemStatus{DatasourceSources: []string{name + "-status"}},
+			}
+			for _, path := range paths {
+				s.Spec.Datasources = append(s.Spec.Datasources, v1beta1.Datasource{Path: path})
+			}
+			return s
+		}
+
+		self := system("self", "shared/path")
+		other := system("other", "shared/path")
+		deleting := system("deleting", "deleting/path")
+		deleting.DeletionTimestamp = &now
+		deleting.Finalizers = []string{"test"}
+		protected := system("protected", "protected/path")
+		protected.DeletionTimestamp = &now
+		protected.Finalizers = []string{"test"}
+		protected.Spec.DeletionProtection = ptr.Bool(true)
+		otherClass := system("other-class", "other-class/path")
+		otherClass.Labels = map[string]string{"styra-controller/class": "other"}
+
+		library := &styrav1alpha1.Library{
+			ObjectMeta: metav1.ObjectMeta{Name: "library", Namespace: "default"},
+			Spec: styrav1alpha1.LibrarySpec{
+				Datasources: []styrav1alpha1.LibraryDatasource{{Path: "library/path"}},
+			},
+		}
+
+		c := fake.NewClientBuilder().
+			WithScheme(scheme).
+			WithObjects(self, other, deleting, protected, otherClass, library).
+			Build()
+
+		inUse, err := datasourceSourcesInUse(context.Background(), c, &configv2alpha2.ProjectConfig{}, self.UID)
+		gomega.Ω(err).NotTo(gomega.HaveOccurred())
+		gomega.Ω(sets.List(inUse)).To(gomega.Equal([]string{
+			"library-path",
+			"other-status",
+			"protected-path",
+			"protected-status",
+			"shared-path",
+		}))
+	})
+})
@@ -0,0 +1,354 @@
+/*
+Copyright (C) 2025 Bankdata (bankdata@bankdata.dk)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package styra
+
+import (
+	"context"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	configv2alpha2 "github.com/bankdata/styra-controller/api/config/v2alpha2"
+	styrav1alpha1 "github.com/bankdata/styra-controller/api/styra/v1alpha1"
+	"github.com/bankdata/styra-controller/api/styra/v1beta1"
+	"github.com/bankdata/styra-controller/internal/labels"
+	"github.com/bankdata/styra-controller/pkg/ocp"
+)
+
+// GarbageCollector removes bundles and sources in OCP which were created by
+// the controller but no longer belong to a System or Library. This happens
+// when a System is deleted while the controller is not running, or when the
+// SystemPrefix or SystemSuffix is changed.
+//
+// Bundles labeled as managed by a controller of the configured class are
+// considered, as are bundles without labels, created by earlier versions of
+// the controller, whose names are in the naming scope of the controller. Such
+// a bundle is orphaned when no System has it as its unique name and it is not
+// labeled as deletion protected. An orphaned bundle is removed together with
+// the git credentials secret of its System, its System source and the typed
+// datasource sources it requires, unless a source is still in use by a
+// System, a Library, another bundle or the default requirements. Other
+// sources required by the bundle, such as Library sources, sources from
+// spec.requirements and sources managed by other tools, are never removed, as
+// the controller does not own them.
+//
+// Sources in the naming scope which are not in use and not required by any
+// bundle are orphaned System sources, and are removed together with their git
+// credentials secret. The naming scope is the unique names formed with the
+// configured SystemPrefix and SystemSuffix. When neither is set, every name is
+// a possible unique name, so only sources of orphaned bundles are removed.
+type GarbageCollector struct {
+	Client client.Reader
+	OCP    ocp.ClientInterface
+	Config *configv2alpha2.ProjectConfig
+	Log    logr.Logger
+
+	// DryRun makes the GarbageCollector only report orphans.
+	DryRun bool
+}
+
+// GarbageCollectionResult holds the orphans found by the GarbageCollector.
+type GarbageCollectionResult struct {
+	Bundles []string
+	Sources []string
+	Secrets []string
+}
+
+// Start runs garbage collection at the configured interval until the context
+// is cancelled. Errors are logged and garbage collection is retried at the
+// next interval. Start implements manager.Runnable.
+func (gc *GarbageCollector) Start(ctx context.Context) error {
+	interval := gc.interval()
+	if interval <= 0 {
+		return nil
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if _, err := gc.Collect(ctx); err != nil {
+				gc.Log.Error(err, "Garbage collection failed")
+			}
+		}
+	}
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable so that only
+// the leader collects garbage.
+func (gc *GarbageCollector) NeedLeaderElection() bool {
+	return true
+}
+
+func (gc *GarbageCollector) interval() time.Duration {
+	if gc.Config.OPAControlPlaneConfig == nil || gc.Config.OPAControlPlaneConfig.GarbageCollection == nil {
+		return 0
+	}
+	return gc.Config.OPAControlPlaneConfig.GarbageCollection.Interval.Duration
+}
+
+// Collect runs garbage collection once and returns the orphans it found. In
+// dry-run mode the orphans are only logged.
+func (gc *GarbageCollector) Collect(ctx context.Context) (*GarbageCollectionResult, error) {
+	log := gc.Log.WithValues("dryRun", gc.DryRun)
+	log.Info("Starting garbage collection")
+
+	// The bundles are listed before the Systems and Libraries, so that the
+	// bundle of a System created during garbage collection is never
+	// considered orphaned.
+	bundles, err := listAllBundles(ctx, gc.OCP)
+	if err != nil {
+		return nil, err
+	}
+
+	sources, err := listAllSources(ctx, gc.OCP)
+	if err != nil {
+		return nil, err
+	}
+
+	inUse, systemNames, err := gc.sourcesInUse(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var orphans []ocp.BundleConfig
+	for _, bundle := range bundles {
+		managed := labels.OCPBundleManaged(bundle.Labels, gc.Config.ControllerClass) ||
+			(len(bundle.Labels) == 0 && gc.inNamingScope(bundle.Name))
+		protected := bundle.Labels[labels.LabelDeletionProtection] == labels.LabelValueDeletionProtected
+		if !managed || systemNames.Has(bundle.Name) || protected {
+			// Bundles which are kept, including those of other controllers,
+			// keep the sources they require.
+			inUse.Insert(bundle.Name)
+			for _, requirement := range bundle.Requirements {
+				inUse.Insert(requirement.Source)
+			}
+			continue
+		}
+		orphans = append(orphans, bundle)
+	}
+
+	result := orphanedResources(orphans, sources, inUse)
+	orphanedSources := sets.New(result.Sources...)
+	for _, source := range sources {
+		if !gc.inNamingScope(source.Name) || inUse.Has(source.Name) || orphanedSources.Has(source.Name) {
+			continue
+		}
+		orphanedSources.Insert(source.Name)
+		result.Secrets = append(result.Secrets, v1beta1.OCPGitSecretID(source.Name))
+	}
+	result.Sources = sets.List(orphanedSources)
+	for _, name := range result.Bundles {
+		log.Info("Found orphaned bundle", "bundle", name)
+		if gc.DryRun {
+			continue
+		}
+		if err := gc.OCP.DeleteBundle(ctx, name); err != nil {
+			return nil, errors.Wrapf(err, "could not delete orphaned bundle %s", name)
+		}
+	}
+	for _, id := range result.Sources {
+		log.Info("Found orphaned source", "source", id)
+		if gc.DryRun {
+			continue
+		}
+		if err := gc.OCP.DeleteSource(ctx, id); err != nil {
+			return nil, errors.Wrapf(err, "could not delete orphaned source %s", id)
+		}
+	}
+	for _, id := range result.Secrets {
+		log.Info("Found orphaned secret", "secret", id)
+		if gc.DryRun {
+			continue
+		}
+		if err := gc.OCP.DeleteSecret(ctx, id); err != nil {
+			return nil, errors.Wrapf(err, "could not delete orphaned secret %s", id)
+		}
+	}
+
+	log.Info("Garbage collection completed",
+		"bundles", len(result.Bundles), "sources", len(result.Sources), "secrets", len(result.Secrets))
+	return result, nil
+}
+
+// sourcesInUse returns the IDs of the sources used by Systems, Libraries and
+// the default requirements, and the unique names of the Systems.
+func (gc *GarbageCollector) sourcesInUse(ctx context.Context) (sets.Set[string], sets.Set[string], error) {
+	inUse := sets.New[string]()
+	systemNames := sets.New[string]()
+
+	if gc.Config.OPAControlPlaneConfig != nil {
+		inUse.Insert(gc.Config.OPAControlPlaneConfig.DefaultRequirements...)
+	}
+
+	var systems v1beta1.SystemList
+	if err := gc.Client.List(ctx, &systems); err != nil {
+		return nil, nil, errors.Wrap(err, "could not list Systems")
+	}
+	for i := range systems.Items {
+		system := &systems.Items[i]
+		if !labels.ControllerClassMatches(system, gc.Config.ControllerClass) {
+			continue
+		}
+		uniqueName := system.OCPUniqueName(gc.Config.SystemPrefix, gc.Config.SystemSuffix)
+		systemNames.Insert(uniqueName)
+		inUse.Insert(uniqueName)
+		for _, datasource := range system.Spec.Datasources {
+			inUse.Insert(datasourceSourceID(datasource.Path))
+		}
+		for _, requirement := range system.Spec.Requirements {
+			inUse.Insert(requirement.Source)
+		}
+	}
+
+	var libraries styrav1alpha1.LibraryList
+	if err := gc.Client.List(ctx, &libraries); err != nil {
+		return nil, nil, errors.Wrap(err, "could not list Libraries")
+	}
+	for _, library := range libraries.Items {
+		inUse.Insert(library.Spec.Name)
+		for _, datasource := range library.Spec.Datasources {
+			inUse.Insert(datasourceSourceID(datasource.Path))
+		}
+	}
+
+	return inUse, systemNames, nil
+}
+
+// inNamingScope reports whether id has the form of the unique names given to
+// Systems with the configured SystemPrefix and SystemSuffix. Without a prefix
+// and suffix no id is considered to be in the naming scope, as any id could
+// then be a unique name.
+func (gc *GarbageCollector) inNamingScope(id string) bool {
+	prefix := uniqueNamePart(gc.Config.SystemPrefix)
+	suffix := uniqueNamePart(gc.Config.SystemSuffix)
+	if prefix == "" && suffix == "" {
+		return false
+	}
+	if prefix != "" && !strings.HasPrefix(id, prefix+"-") {
+		return false
+	}
+	return suffix == "" || strings.HasSuffix(id, "-"+suffix)
+}
+
+// uniqueNamePart returns a SystemPrefix or SystemSuffix as it appears in the
+// unique names of Systems, see System.OCPUniqueName.
+func uniqueNamePart(s string) string {
+	return strings.ReplaceAll(strings.Trim(path.Clean("/"+s), "/"), "/", "-")
+}
+
+// orphanedResources returns the orphaned bundles along with their git
+// credentials secrets and the sources owned by the controller which they
+// require and which are not in use. These are the System source, named like
+// the bundle, and the typed datasource sources along with their credentials.
+func orphanedResources(
+	orphans []ocp.BundleConfig,
+	sources []ocp.SourceConfig,
+	inUse sets.Set[string],
+) *GarbageCollectionResult {
+	datasourceSources := make(map[string]ocp.SourceConfig)
+	for _, source := range sources {
+		if isDatasourceSource(source) {
+			datasourceSources[source.Name] = source
+		}
+	}
+
+	result := &GarbageCollectionResult{}
+	orphanedSources := sets.New[string]()
+	for _, bundle := range orphans {
+		result.Bundles = append(result.Bundles, bundle.Name)
+		// The bundle is named after the unique name of its System, which may
+		// have been created with another SystemPrefix or SystemSuffix.
+		result.Secrets = append(result.Secrets, v1beta1.OCPGitSecretID(bundle.Name))
+		if !inUse.Has(bundle.Name) {
+			orphanedSources.Insert(bundle.Name)
+		}
+		for _, requirement := range bundle.Requirements {
+			source, ok := datasourceSources[requirement.Source]
+			if !ok || inUse.Has(source.Name) || orphanedSources.Has(source.Name) {
+				continue
+			}
+			orphanedSources.Insert(source.Name)
+			if credentials := source.Datasources[0].Credentials; credentials != nil {
+				result.Secrets = append(result.Secrets, credentials.Name)
+			}
+		}
+	}
+	result.Sources = sets.List(orphanedSources)
+	return result
+}
+
+// isDatasourceSource reports whether the source has the form of the sources
+// the controller creates for datasources with a type, see datasourceSource.
+func isDatasourceSource(source ocp.SourceConfig) bool {
+	if len(source.Datasources) != 1 || source.Builtin != nil || source.Git.Repo != "" ||
+		len(source.EmbeddedFiles) > 0 || len(source.Requirements) > 0 {
+		return false
+	}
+	datasource := source.Datasources[0]
+	if datasource.Name != source.Name || datasourceSourceID(datasource.Path) != source.Name {
+		return false
+	}
+	return datasource.Credentials == nil || datasource.Credentials.Name == datasourceSecretID(source.Name)
+}
+
+// listAllSources lists the sources in OCP, following pagination.
+func listAllSources(ctx context.Context, ocpClient ocp.ClientInterface) ([]ocp.SourceConfig, error) {
+	var (
+		sources []ocp.SourceConfig
+		cursor  string
+	)
+	for {
+		res, err := ocpClient.ListSources(ctx, cursor)
+		if err != nil {
+			return nil, errors.Wrap(err, "could not list sources in OCP")
+		}
+		sources = append(sources, res.Sources...)
+		if res.NextCursor == "" {
+			return sources, nil
+		}
+		cursor = res.NextCursor
+	}
+}
+
+// listAllBundles lists the bundles in OCP, following pagination.
+func listAllBundles(ctx context.Context, ocpClient ocp.ClientInterface) ([]ocp.BundleConfig, error) {
+	var (
+		bundles []ocp.BundleConfig
+		cursor  string
+	)
+	for {
+		res, err := ocpClient.ListBundles(ctx, cursor)
+		if err != nil {
+			return nil, errors.Wrap(err, "could not list bundles in OCP")
+		}
+		bundles = append(bundles, res.Bundles...)
+		if res.NextCursor == "" {
+			return bundles, nil
+		}
+		cursor = res.NextCursor
+	}
+}
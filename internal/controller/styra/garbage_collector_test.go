@@ -0,0 +1,262 @@
+/*
+Copyright (C) 2025 Bankdata (bankdata@bankdata.dk)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package styra
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+	ginkgo "github.com/onsi/ginkgo/v2"
+	gomega "github.com/onsi/gomega"
+	"github.com/stretchr/testify/mock"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	configv2alpha2 "github.com/bankdata/styra-controller/api/config/v2alpha2"
+	styrav1alpha1 "github.com/bankdata/styra-controller/api/styra/v1alpha1"
+	"github.com/bankdata/styra-controller/api/styra/v1beta1"
+	"github.com/bankdata/styra-controller/internal/labels"
+	"github.com/bankdata/styra-controller/pkg/ocp"
+	"github.com/bankdata/styra-controller/pkg/ocp/mocks"
+)
+
+var _ = ginkgo.Describe("GarbageCollector", func() {
+	var (
+		ocpClient *mocks.ClientInterface
+		gc        *GarbageCollector
+	)
+
+	bundle := func(name string, bundleLabels map[string]string, sources ...string) ocp.BundleConfig {
+		return ocp.BundleConfig{
+			Name:         name,
+			Labels:       bundleLabels,
+			Requirements: ocp.ToRequirements(sources),
+		}
+	}
+
+	ginkgo.BeforeEach(func() {
+		scheme := runtime.NewScheme()
+		gomega.Expect(v1beta1.AddToScheme(scheme)).To(gomega.Succeed())
+		gomega.Expect(styrav1alpha1.AddToScheme(scheme)).To(gomega.Succeed())
+
+		protectedLabels := labels.OCPBundleLabels("", "default", "protected")
+		protectedLabels[labels.LabelDeletionProtection] = labels.LabelValueDeletionProtected
+
+		ocpClient = &mocks.ClientInterface{}
+		ocpClient.On("ListBundles", mock.Anything, "").Return(&ocp.ListBundlesResponse{
+			Bundles: []ocp.BundleConfig{
+				bundle("default-system", labels.OCPBundleLabels("", "default", "system"),
+					"default-system", "shared-datasource"),
+				bundle("default-deleted", labels.OCPBundleLabels("", "default", "deleted"),
+					"default-deleted", "shared-datasource", "orphaned-datasource", "library", "base-library",
+					"required-source", "protected-library", "external"),
+			},
+			NextCursor: "next",
+		}, nil)
+		ocpClient.On("ListBundles", mock.Anything, "next").Return(&ocp.ListBundlesResponse{
+			Bundles: []ocp.BundleConfig{
+				bundle("default-protected", protectedLabels, "default-protected"),
+				bundle("other-class", labels.OCPBundleLabels("other", "default", "other"), "other-class"),
+				bundle("unmanaged", nil, "unmanaged"),
+			},
+		}, nil)
+		ocpClient.On("ListSources", mock.Anything, "").Return(&ocp.ListSourcesResponse{
+			Sources: []ocp.SourceConfig{
+				{Name: "default-system"},
+				{Name: "unrelated"},
+				{
+					Name: "orphaned-datasource",
+					Datasources: []ocp.Datasource{{
+						Name:        "orphaned-datasource",
+						Path:        "orphaned/datasource",
+						Type:        "http",
+						Credentials: &ocp.SecretRef{Name: "orphaned-datasource-credentials"},
+					}},
+				},
+				// The source of a deleted, deletion protected Library.
+				{Name: "protected-library", Git: ocp.GitConfig{Repo: "https://github.com/org/library"}},
+				// A source managed outside the controller.
+				{
+					Name:        "external",
+					Datasources: []ocp.Datasource{{Name: "users", Path: "users", Type: "http"}},
+				},
+			},
+		}, nil)
+
+		gc = &GarbageCollector{
+			Client: fake.NewClientBuilder().
+				WithScheme(scheme).
+				WithObjects(
+					&v1beta1.System{
+						ObjectMeta: metav1.ObjectMeta{Name: "system", Namespace: "default"},
+						Spec: v1beta1.SystemSpec{
+							Datasources:  []v1beta1.Datasource{{Path: "shared/datasource"}},
+							Requirements: []v1beta1.Requirement{{Source: "required-source"}},
+						},
+					},
+					&styrav1alpha1.Library{
+						ObjectMeta: metav1.ObjectMeta{Name: "library"},
+						Spec:       styrav1alpha1.LibrarySpec{Name: "library"},
+					},
+				).
+				Build(),
+			OCP: ocpClient,
+			Config: &configv2alpha2.ProjectConfig{
+				OPAControlPlaneConfig: &configv2alpha2.OPAControlPlaneConfig{
+					DefaultRequirements: []string{"base-library"},
+				},
+			},
+			Log: logr.Discard(),
+		}
+	})
+
+	expected := &GarbageCollectionResult{
+		Bundles: []string{"default-deleted"},
+		Sources: []string{"default-deleted", "orphaned-datasource"},
+		Secrets: []string{"default-deleted-git", "orphaned-datasource-credentials"},
+	}
+
+	ginkgo.It("only reports orphans in dry-run mode", func() {
+		gc.DryRun = true
+		result, err := gc.Collect(context.Background())
+		gomega.Ω(err).NotTo(gomega.HaveOccurred())
+		gomega.Ω(result).To(gomega.Equal(expected))
+		ocpClient.AssertNotCalled(ginkgo.GinkgoT(), "DeleteBundle", mock.Anything, mock.Anything)
+		ocpClient.AssertNotCalled(ginkgo.GinkgoT(), "DeleteSource", mock.Anything, mock.Anything)
+		ocpClient.AssertNotCalled(ginkgo.GinkgoT(), "DeleteSecret", mock.Anything, mock.Anything)
+	})
+
+	ginkgo.It("deletes orphans", func() {
+		ocpClient.On("DeleteBundle", mock.Anything, "default-deleted").Return(nil).Once()
+		ocpClient.On("DeleteSource", mock.Anything, "default-deleted").Return(nil).Once()
+		ocpClient.On("DeleteSource", mock.Anything, "orphaned-datasource").Return(nil).Once()
+		ocpClient.On("DeleteSecret", mock.Anything, "default-deleted-git").Return(nil).Once()
+		ocpClient.On("DeleteSecret", mock.Anything, "orphaned-datasource-credentials").Return(nil).Once()
+
+		result, err := gc.Collect(context.Background())
+		gomega.Ω(err).NotTo(gomega.HaveOccurred())
+		gomega.Ω(result).To(gomega.Equal(expected))
+		ocpClient.AssertExpectations(ginkgo.GinkgoT())
+	})
+
+	ginkgo.It("does not delete sources of orphaned bundles which it does not own", func() {
+		ocpClient.On("DeleteBundle", mock.Anything, mock.Anything).Return(nil)
+		ocpClient.On("DeleteSource", mock.Anything, mock.Anything).Return(nil)
+		ocpClient.On("DeleteSecret", mock.Anything, mock.Anything).Return(nil)
+
+		_, err := gc.Collect(context.Background())
+		gomega.Ω(err).NotTo(gomega.HaveOccurred())
+		for _, source := range []string{"external", "protected-library", "required-source", "library", "base-library"} {
+			ocpClient.AssertNotCalled(ginkgo.GinkgoT(), "DeleteSource", mock.Anything, source)
+		}
+	})
+})
+
+var _ = ginkgo.Describe("GarbageCollector ordering", func() {
+	ginkgo.It("does not collect the bundle of a System created after the bundles are listed", func() {
+		scheme := runtime.NewScheme()
+		gomega.Expect(v1beta1.AddToScheme(scheme)).To(gomega.Succeed())
+		gomega.Expect(styrav1alpha1.AddToScheme(scheme)).To(gomega.Succeed())
+		c := fake.NewClientBuilder().WithScheme(scheme).Build()
+
+		ocpClient := &mocks.ClientInterface{}
+		ocpClient.On("ListSources", mock.Anything, "").Return(&ocp.ListSourcesResponse{}, nil)
+		ocpClient.On("ListBundles", mock.Anything, "").Return(&ocp.ListBundlesResponse{
+			Bundles: []ocp.BundleConfig{{
+				Name:         "default-new",
+				Labels:       labels.OCPBundleLabels("", "default", "new"),
+				Requirements: ocp.ToRequirements([]string{"default-new"}),
+			}},
+		}, nil).Run(func(mock.Arguments) {
+			// The System is created after OCP has been listed.
+			gomega.Expect(c.Create(context.Background(), &v1beta1.System{
+				ObjectMeta: metav1.ObjectMeta{Name: "new", Namespace: "default"},
+			})).To(gomega.Succeed())
+		})
+
+		gc := &GarbageCollector{
+			Client: c,
+			OCP:    ocpClient,
+			Config: &configv2alpha2.ProjectConfig{},
+			Log:    logr.Discard(),
+		}
+		result, err := gc.Collect(context.Background())
+		gomega.Ω(err).NotTo(gomega.HaveOccurred())
+		gomega.Ω(result.Bundles).To(gomega.BeEmpty())
+		gomega.Ω(result.Sources).To(gomega.BeEmpty())
+		ocpClient.AssertNotCalled(ginkgo.GinkgoT(), "DeleteBundle", mock.Anything, mock.Anything)
+	})
+})
+
+var _ = ginkgo.Describe("GarbageCollector naming scope", func() {
+	ginkgo.It("collects unlabeled bundles and unused sources in the naming scope", func() {
+		scheme := runtime.NewScheme()
+		gomega.Expect(v1beta1.AddToScheme(scheme)).To(gomega.Succeed())
+		gomega.Expect(styrav1alpha1.AddToScheme(scheme)).To(gomega.Succeed())
+
+		ocpClient := &mocks.ClientInterface{}
+		ocpClient.On("ListBundles", mock.Anything, "").Return(&ocp.ListBundlesResponse{
+			Bundles: []ocp.BundleConfig{
+				{Name: "cluster-default-system", Requirements: ocp.ToRequirements([]string{"cluster-default-system"})},
+				{Name: "cluster-default-old", Requirements: ocp.ToRequirements([]string{"cluster-default-old", "shared"})},
+				{Name: "other-bundle", Requirements: ocp.ToRequirements([]string{"shared", "cluster-default-kept"})},
+			},
+		}, nil)
+		ocpClient.On("ListSources", mock.Anything, "").Return(&ocp.ListSourcesResponse{
+			Sources:    []ocp.SourceConfig{{Name: "cluster-default-system"}, {Name: "cluster-default-gone"}},
+			NextCursor: "next",
+		}, nil)
+		ocpClient.On("ListSources", mock.Anything, "next").Return(&ocp.ListSourcesResponse{
+			Sources: []ocp.SourceConfig{{Name: "cluster-default-kept"}, {Name: "library"}, {Name: "datasource"}},
+		}, nil)
+
+		gc := &GarbageCollector{
+			Client: fake.NewClientBuilder().
+				WithScheme(scheme).
+				WithObjects(&v1beta1.System{
+					ObjectMeta: metav1.ObjectMeta{Name: "system", Namespace: "default"},
+				}).
+				Build(),
+			OCP:    ocpClient,
+			Config: &configv2alpha2.ProjectConfig{SystemPrefix: "cluster/"},
+			Log:    logr.Discard(),
+			DryRun: true,
+		}
+
+		result, err := gc.Collect(context.Background())
+		gomega.Ω(err).NotTo(gomega.HaveOccurred())
+		gomega.Ω(result).To(gomega.Equal(&GarbageCollectionResult{
+			Bundles: []string{"cluster-default-old"},
+			Sources: []string{"cluster-default-gone", "cluster-default-old"},
+			Secrets: []string{"cluster-default-old-git", "cluster-default-gone-git"},
+		}))
+	})
+})
+
+var _ = ginkgo.DescribeTable("GarbageCollector.inNamingScope",
+	func(prefix, suffix, id string, expected bool) {
+		gc := &GarbageCollector{Config: &configv2alpha2.ProjectConfig{SystemPrefix: prefix, SystemSuffix: suffix}}
+		gomega.Ω(gc.inNamingScope(id)).To(gomega.Equal(expected))
+	},
+	ginkgo.Entry("no prefix or suffix", "", "", "default-system", false),
+	ginkgo.Entry("prefix", "cluster", "", "cluster-default-system", true),
+	ginkgo.Entry("nested prefix", "a/b/", "", "a-b-default-system", true),
+	ginkgo.Entry("other prefix", "cluster", "", "clusters-default-system", false),
+	ginkgo.Entry("suffix", "", "dev", "default-system-dev", true),
+	ginkgo.Entry("prefix and suffix", "cluster", "dev", "cluster-default-system-prod", false),
+)
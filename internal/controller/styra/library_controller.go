@@ -19,25 +19,42 @@ package styra
 import (
 	"context"
 	"fmt"
+	"slices"
 	"strings"
+	"time"
 
+	ctrlerr "github.com/bankdata/styra-controller/internal/errors"
+	"github.com/bankdata/styra-controller/internal/finalizer"
+	"github.com/bankdata/styra-controller/internal/gitcredentials"
 	"github.com/bankdata/styra-controller/internal/predicate"
 	"github.com/bankdata/styra-controller/internal/webhook"
 	"github.com/go-logr/logr"
 	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
 
+	corev1 "k8s.io/api/core/v1"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/events"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	ctrlpred "sigs.k8s.io/controller-runtime/pkg/predicate"
 
 	configv2alpha2 "github.com/bankdata/styra-controller/api/config/v2alpha2"
 	styrav1alpha1 "github.com/bankdata/styra-controller/api/styra/v1alpha1"
 	"github.com/bankdata/styra-controller/pkg/ocp"
 )
 
+// LibraryReconcilerMetrics holds the metrics for the LibraryReconciler
+type LibraryReconcilerMetrics struct {
+	ControllerLibraryStatusReady *prometheus.GaugeVec
+	ReconcileSegmentTime         *prometheus.HistogramVec
+	ReconcileTime                *prometheus.HistogramVec
+}
+
 // LibraryReconciler reconciles a Library object
 type LibraryReconciler struct {
 	client.Client
@@ -45,6 +62,8 @@ type LibraryReconciler struct {
 	OCP           ocp.ClientInterface
 	Config        *configv2alpha2.ProjectConfig
 	WebhookClient webhook.Client
+	Recorder      events.EventRecorder
+	Metrics       *LibraryReconcilerMetrics
 }
 
 //+kubebuilder:rbac:groups=styra.bankdata.dk,resources=libraries,verbs=get;list;watch;create;update;patch;delete
@@ -55,6 +74,7 @@ type LibraryReconciler struct {
 // ensuring that the current state of the Library resource renconciled
 // towards the desired state.
 func (r *LibraryReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	start := time.Now()
 	log := log.FromContext(ctx)
 	log.Info("Reconciliation of libraries begins")
 
@@ -62,16 +82,218 @@ func (r *LibraryReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 	if err := r.Get(ctx, req.NamespacedName, &k8sLib); err != nil {
 		if k8serrors.IsNotFound(err) {
 			log.Info("Could not find Library")
+			r.observeReconcileTime("delete", start)
 			return ctrl.Result{}, nil
 		}
 		return ctrl.Result{}, errors.Wrap(err, "Could not get Library")
 	}
 
-	if !k8sLib.ObjectMeta.DeletionTimestamp.IsZero() {
-		log.Info(fmt.Sprintf("Library %s is under deletion in k8s. Ignoring it.", k8sLib.Spec.Name))
+	var (
+		res ctrl.Result
+		err error
+	)
+
+	if k8sLib.ObjectMeta.DeletionTimestamp.IsZero() {
+		res, err = r.reconcile(ctx, log, &k8sLib)
+		r.updateMetric(req, k8sLib.Spec.Name, k8sLib.Status.Ready)
+	} else {
+		res, err = r.reconcileDeletion(ctx, log, &k8sLib)
+		if err != nil {
+			r.updateMetric(req, k8sLib.Spec.Name, k8sLib.Status.Ready)
+		} else {
+			r.deleteMetrics(req)
+			r.observeReconcileTime("delete", start)
+			return res, nil
+		}
+	}
+
+	if err != nil {
+		log.Error(err, "Reconciliation failed")
+		r.recordErrorEvent(&k8sLib, err)
+		r.setLibraryStatusError(&k8sLib, err)
+
+		r.observeReconcileTime("error", start)
+		if err := r.Status().Update(ctx, &k8sLib); err != nil {
+			return res, errors.Wrap(err, "could not set failure status on Library")
+		}
+	} else {
+		r.observeReconcileTime("ok", start)
+	}
+	return res, err
+}
+
+// observeReconcileTime records the duration of a reconciliation with the
+// given result.
+func (r *LibraryReconciler) observeReconcileTime(result string, start time.Time) {
+	if r.Metrics == nil || r.Metrics.ReconcileTime == nil {
+		return
+	}
+	r.Metrics.ReconcileTime.WithLabelValues(result).Observe(time.Since(start).Seconds())
+}
+
+// observeSegmentTime records the duration of a segment of a reconciliation.
+func (r *LibraryReconciler) observeSegmentTime(segment string, start time.Time) {
+	if r.Metrics == nil || r.Metrics.ReconcileSegmentTime == nil {
+		return
+	}
+	r.Metrics.ReconcileSegmentTime.WithLabelValues(segment).Observe(time.Since(start).Seconds())
+}
+
+func (r *LibraryReconciler) updateMetric(req ctrl.Request, libraryID string, ready bool) {
+	if r.Metrics == nil || r.Metrics.ControllerLibraryStatusReady == nil {
+		return
+	}
+
+	var value float64
+	if ready {
+		value = 1
+	}
+	r.Metrics.ControllerLibraryStatusReady.WithLabelValues(req.Name, libraryID).Set(value)
+}
+
+func (r *LibraryReconciler) deleteMetrics(req ctrl.Request) {
+	if r.Metrics == nil || r.Metrics.ControllerLibraryStatusReady == nil {
+		return
+	}
+	if deleted := r.Metrics.ControllerLibraryStatusReady.DeletePartialMatch(
+		prometheus.Labels{"library_name": req.Name},
+	); deleted > 1 {
+		log.Log.Error(errors.New("Failed to delete metric"), "Incorrect number of deleted metrics", "deleted", deleted)
+	}
+}
+
+func (r *LibraryReconciler) setLibraryStatusError(k8sLib *styrav1alpha1.Library, err error) {
+	k8sLib.Status.FailureMessage = err.Error()
+	k8sLib.Status.Phase = styrav1alpha1.LibraryPhaseFailed
+	k8sLib.Status.Ready = false
+
+	var rerr *ctrlerr.ReconcilerErr
+	if errors.As(err, &rerr) {
+		if rerr.ConditionType != "" {
+			k8sLib.SetCondition(styrav1alpha1.ConditionType(rerr.ConditionType), metav1.ConditionFalse)
+		}
+	}
+}
+
+func (r *LibraryReconciler) recordErrorEvent(k8sLib *styrav1alpha1.Library, err error) {
+	if r.Recorder == nil {
+		return
+	}
+	var rerr *ctrlerr.ReconcilerErr
+	if errors.As(err, &rerr) {
+		if rerr.Event != "" {
+			r.Recorder.Eventf(k8sLib, nil, corev1.EventTypeWarning, rerr.Event, "Reconcile", rerr.Error())
+		}
+	}
+}
+
+func (r *LibraryReconciler) reconcileFinalizer(
+	ctx context.Context,
+	log logr.Logger,
+	k8sLib *styrav1alpha1.Library,
+) error {
+	log.Info("Ensuring finalizer is present")
+	finalizer.Add(k8sLib)
+	if err := r.Update(ctx, k8sLib); err != nil {
+		return ctrlerr.Wrap(err, "Could not set finalizer").
+			WithLibraryEvent(styrav1alpha1.EventErrorSetFinalizer)
+	}
+	return nil
+}
+
+func (r *LibraryReconciler) reconcileDeletion(
+	ctx context.Context,
+	log logr.Logger,
+	k8sLib *styrav1alpha1.Library,
+) (ctrl.Result, error) {
+	log.Info(fmt.Sprintf("Library %s deletion is in progress", k8sLib.Spec.Name))
+	if !finalizer.IsSet(k8sLib) {
 		return ctrl.Result{}, nil
 	}
 
+	if !deletionProtected(k8sLib.Spec.DeletionProtection, r.Config.DeletionProtectionDefault) {
+		// Systems stop requiring the Library once they have reconciled, unless
+		// they require it explicitly, so deletion waits for their bundles.
+		requiredBy, err := bundlesRequiringSource(ctx, r.OCP, k8sLib.Spec.Name)
+		if err != nil {
+			return ctrl.Result{}, ctrlerr.Wrap(err, "Could not determine bundles requiring the library source").
+				WithLibraryEvent(styrav1alpha1.EventErrorDeleteSourceInOCP)
+		}
+		if len(requiredBy) > 0 {
+			return ctrl.Result{}, ctrlerr.New(fmt.Sprintf("Library source %s is required by bundles: %s",
+				k8sLib.Spec.Name, strings.Join(requiredBy, ", "))).
+				WithLibraryEvent(styrav1alpha1.EventErrorSourceInUse)
+		}
+
+		log.Info("Deleting source for library in OCP", "source", k8sLib.Spec.Name)
+		deleteLibrarySourceStart := time.Now()
+		err = r.OCP.DeleteSource(ctx, k8sLib.Spec.Name)
+		r.observeSegmentTime("deleteLibrarySourceOcp", deleteLibrarySourceStart)
+		if err != nil {
+			return ctrl.Result{}, ctrlerr.Wrap(err, "Could not delete library source in OCP").
+				WithLibraryEvent(styrav1alpha1.EventErrorDeleteSourceInOCP)
+		}
+
+		if len(k8sLib.Spec.Datasources) > 0 {
+			inUse, err := datasourceSourcesInUse(ctx, r, r.Config, k8sLib.UID)
+			if err != nil {
+				return ctrl.Result{}, ctrlerr.Wrap(err, "Could not determine datasources in use").
+					WithLibraryEvent(styrav1alpha1.EventErrorDeleteSourceInOCP)
+			}
+			for _, datasource := range k8sLib.Spec.Datasources {
+				datasourceID := datasourceSourceID(datasource.Path)
+				if inUse.Has(datasourceID) {
+					log.Info("Keeping datasource source as it is used by other resources", "source", datasourceID)
+					continue
+				}
+				if err := r.OCP.DeleteSource(ctx, datasourceID); err != nil {
+					return ctrl.Result{}, ctrlerr.Wrap(err, "Could not delete library datasource source in OCP").
+						WithLibraryEvent(styrav1alpha1.EventErrorDeleteSourceInOCP)
+				}
+			}
+		}
+	}
+
+	log.Info("Removing finalizer")
+	finalizer.Remove(k8sLib)
+	if err := r.Update(ctx, k8sLib); err != nil {
+		return ctrl.Result{}, ctrlerr.Wrap(err, "Could not remove finalizer").
+			WithLibraryEvent(styrav1alpha1.EventErrorRemovingFinalizer)
+	}
+	return ctrl.Result{}, nil
+}
+
+// bundlesRequiringSource returns the sorted names of the bundles in OCP which
+// require the source with the given ID.
+func bundlesRequiringSource(ctx context.Context, ocpClient ocp.ClientInterface, id string) ([]string, error) {
+	bundles, err := listAllBundles(ctx, ocpClient)
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, bundle := range bundles {
+		if slices.ContainsFunc(bundle.Requirements, func(requirement ocp.Requirement) bool {
+			return requirement.Source == id
+		}) {
+			names = append(names, bundle.Name)
+		}
+	}
+	slices.Sort(names)
+	return names, nil
+}
+
+func (r *LibraryReconciler) reconcile(
+	ctx context.Context,
+	log logr.Logger,
+	k8sLib *styrav1alpha1.Library,
+) (ctrl.Result, error) {
+	if !finalizer.IsSet(k8sLib) {
+		if err := r.reconcileFinalizer(ctx, log, k8sLib); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
 	log.Info("OPA Control Plane library reconcile starting")
 	return r.ocpReconcile(ctx, log, k8sLib)
 }
@@ -79,28 +301,84 @@ func (r *LibraryReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 func (r *LibraryReconciler) ocpReconcile(
 	ctx context.Context,
 	log logr.Logger,
-	k8sLib styrav1alpha1.Library) (ctrl.Result, error) {
+	k8sLib *styrav1alpha1.Library) (ctrl.Result, error) {
 	log.Info("Reconciling Library")
 
-	reconcileLibrarySourceResult, err := r.reconcileLibrarySource(ctx, log, k8sLib)
+	reconcileDatasourcesStart := time.Now()
+	var requirements []ocp.Requirement
+	for _, datasource := range k8sLib.Spec.Datasources {
+		datasourceID := datasourceSourceID(datasource.Path)
+
+		created, err := createSourceIfNotExists(ctx, log, r.OCP, datasourceID)
+		if err != nil {
+			return ctrl.Result{}, ctrlerr.Wrap(err,
+				fmt.Sprintf("ocpReconcile: Could not ensure datasource/source exists: %s", datasourceID),
+			).WithLibraryEvent(styrav1alpha1.EventErrorUpdateSource).
+				WithLibraryCondition(styrav1alpha1.ConditionTypeDatasourcesUpdated)
+		}
+
+		if created && r.WebhookClient != nil {
+			log.Info("Calling library datasource changed webhook")
+			if err := r.WebhookClient.LibraryDatasourceChangedOCP(ctx, log, datasourceID); err != nil {
+				err = ctrlerr.Wrap(err, "Could not call library datasource changed webhook").
+					WithLibraryEvent(styrav1alpha1.EventErrorCallWebhook).
+					WithLibraryCondition(styrav1alpha1.ConditionTypeDatasourcesUpdated)
+				r.recordErrorEvent(k8sLib, err)
+				log.Error(err, err.Error())
+			}
+		}
+
+		requirements = append(requirements, ocp.NewRequirement(datasourceID))
+	}
+	r.observeSegmentTime("reconcileLibraryDatasourcesOcp", reconcileDatasourcesStart)
+	k8sLib.SetCondition(styrav1alpha1.ConditionTypeDatasourcesUpdated, metav1.ConditionTrue)
+
+	reconcileLibrarySourceStart := time.Now()
+	reconcileLibrarySourceResult, err := r.reconcileLibrarySource(ctx, log, k8sLib, requirements)
+	r.observeSegmentTime("reconcileLibrarySourceOcp", reconcileLibrarySourceStart)
 	if err != nil {
 		return reconcileLibrarySourceResult, err
 	}
+	k8sLib.SetCondition(styrav1alpha1.ConditionTypeSourceUpdated, metav1.ConditionTrue)
+
+	k8sLib.Status.Ready = true
+	k8sLib.Status.Phase = styrav1alpha1.LibraryPhaseCreated
+	k8sLib.Status.FailureMessage = ""
+	k8sLib.Status.ObservedGeneration = k8sLib.Generation
 
-	log.Info("Reconciliation completed")
+	updateStatusStart := time.Now()
+	err = r.Status().Update(ctx, k8sLib)
+	r.observeSegmentTime("updateStatusOcp", updateStatusStart)
+	if err != nil {
+		return ctrl.Result{}, ctrlerr.Wrap(err, "Could not change status.phase to Created").
+			WithLibraryEvent(styrav1alpha1.EventErrorPhaseToCreated)
+	}
+
+	msg := "OPA Control Plane reconciliation completed"
+	if r.Recorder != nil {
+		r.Recorder.Eventf(k8sLib, nil, corev1.EventTypeNormal, "ReconciliationCompleted", "Reconcile", msg)
+	}
+	log.Info(msg)
 	return ctrl.Result{}, nil
 }
 
 func (r *LibraryReconciler) reconcileLibrarySource(
 	ctx context.Context,
 	log logr.Logger,
-	k8sLib styrav1alpha1.Library) (ctrl.Result, error) {
+	k8sLib *styrav1alpha1.Library,
+	requirements []ocp.Requirement) (ctrl.Result, error) {
+	if k8sLib.Spec.SourceControl == nil || k8sLib.Spec.SourceControl.LibraryOrigin == nil {
+		return ctrl.Result{}, ctrlerr.New("reconcileLibrarySource: no source control configured on library").
+			WithLibraryEvent(styrav1alpha1.EventErrorUpdateSource).
+			WithLibraryCondition(styrav1alpha1.ConditionTypeSourceUpdated)
+	}
+
 	gitConfig := &ocp.GitConfig{
-		Repo:          k8sLib.Spec.SourceControl.LibraryOrigin.URL,
-		IncludedFiles: []string{"*.rego"},
-		ExcludedFiles: []string{"*_test.rego"},
-		Path:          ".",
+		Repo: k8sLib.Spec.SourceControl.LibraryOrigin.URL,
+		Path: ".",
 	}
+	gitConfig.IncludedFiles, gitConfig.ExcludedFiles = gitFilePatterns(r.Config,
+		k8sLib.Spec.SourceControl.LibraryOrigin.IncludedFiles, k8sLib.Spec.SourceControl.LibraryOrigin.ExcludedFiles)
 	if k8sLib.Spec.SourceControl.LibraryOrigin.Path != "" {
 		gitConfig.Path = k8sLib.Spec.SourceControl.LibraryOrigin.Path
 	}
@@ -111,27 +389,27 @@ func (r *LibraryReconciler) reconcileLibrarySource(
 		gitConfig.Reference = k8sLib.Spec.SourceControl.LibraryOrigin.Reference
 	}
 
-	gitCredentialFound := false
-	for _, cred := range r.Config.OPAControlPlaneConfig.GitCredentials {
-		if strings.Contains(k8sLib.Spec.SourceControl.LibraryOrigin.URL, cred.RepoPrefix) {
-			gitConfig.CredentialID = cred.ID
-			gitCredentialFound = true
-			break
-		}
-	}
-	if !gitCredentialFound {
-		return ctrl.Result{}, fmt.Errorf(
-			"createLibrarySource: Unsupported git repository: %s",
-			k8sLib.Spec.SourceControl.LibraryOrigin.URL,
-		)
+	// Libraries are cluster-scoped, so they are not restricted by the
+	// namespace allowlists of the credentials.
+	cred, err := gitcredentials.Resolve(r.Config.OPAControlPlaneConfig.GitCredentials,
+		k8sLib.Spec.SourceControl.LibraryOrigin.URL, "")
+	if err != nil {
+		return ctrl.Result{}, ctrlerr.Wrap(err, "reconcileLibrarySource: Unsupported git repository").
+			WithLibraryEvent(styrav1alpha1.EventErrorResolveCredentials).
+			WithLibraryCondition(styrav1alpha1.ConditionTypeCredentialsResolved)
 	}
+	gitConfig.CredentialID = cred.ID
+	k8sLib.SetCondition(styrav1alpha1.ConditionTypeCredentialsResolved, metav1.ConditionTrue)
 
-	_, err := r.OCP.PutSource(ctx, k8sLib.Spec.Name, &ocp.PutSourceRequest{
-		Name: k8sLib.Spec.Name,
-		Git:  gitConfig,
+	_, err = r.OCP.PutSource(ctx, k8sLib.Spec.Name, &ocp.PutSourceRequest{
+		Name:         k8sLib.Spec.Name,
+		Git:          gitConfig,
+		Requirements: requirements,
 	})
 	if err != nil {
-		return ctrl.Result{}, errors.Wrap(err, "createLibrarySource: could not create or update source in OCP")
+		return ctrl.Result{}, ctrlerr.Wrap(err, "reconcileLibrarySource: could not create or update source in OCP").
+			WithLibraryEvent(styrav1alpha1.EventErrorUpdateSource).
+			WithLibraryCondition(styrav1alpha1.ConditionTypeSourceUpdated)
 	}
 	log.Info("OCP source upserted", "source", k8sLib.Spec.Name)
 	return ctrl.Result{}, nil
@@ -144,6 +422,10 @@ func (r *LibraryReconciler) SetupWithManager(mgr ctrl.Manager) error {
 		return err
 	}
 
+	// Only reconcile when the spec or labels change. This prevents finalizer
+	// updates from triggering another reconcile.
+	p = ctrlpred.And(p, ctrlpred.Or(ctrlpred.GenerationChangedPredicate{}, ctrlpred.LabelChangedPredicate{}))
+
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&styrav1alpha1.Library{}, builder.WithPredicates(p)).
 		Complete(r)
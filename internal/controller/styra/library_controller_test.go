@@ -0,0 +1,111 @@
+/*
+Copyright (C) 2025 Bankdata (bankdata@bankdata.dk)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package styra
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/go-logr/logr"
+	ginkgo "github.com/onsi/ginkgo/v2"
+	gomega "github.com/onsi/gomega"
+	"github.com/stretchr/testify/mock"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	configv2alpha2 "github.com/bankdata/styra-controller/api/config/v2alpha2"
+	styrav1alpha1 "github.com/bankdata/styra-controller/api/styra/v1alpha1"
+	ctrlerr "github.com/bankdata/styra-controller/internal/errors"
+	"github.com/bankdata/styra-controller/internal/finalizer"
+	"github.com/bankdata/styra-controller/pkg/ocp"
+	"github.com/bankdata/styra-controller/pkg/ocp/mocks"
+)
+
+var _ = ginkgo.Describe("library deletion", func() {
+	var (
+		ocpClient  *mocks.ClientInterface
+		reconciler *LibraryReconciler
+		library    *styrav1alpha1.Library
+	)
+
+	ginkgo.BeforeEach(func() {
+		scheme := runtime.NewScheme()
+		gomega.Expect(styrav1alpha1.AddToScheme(scheme)).To(gomega.Succeed())
+
+		library = &styrav1alpha1.Library{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              "library",
+				DeletionTimestamp: &metav1.Time{Time: time.Now()},
+			},
+			Spec: styrav1alpha1.LibrarySpec{Name: "library"},
+		}
+		finalizer.Add(library)
+
+		ocpClient = &mocks.ClientInterface{}
+		reconciler = &LibraryReconciler{
+			Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(library).Build(),
+			OCP:    ocpClient,
+			Config: &configv2alpha2.ProjectConfig{},
+		}
+	})
+
+	ginkgo.It("deletes the source when no bundle requires it", func() {
+		ocpClient.On("ListBundles", mock.Anything, "").Return(&ocp.ListBundlesResponse{
+			Bundles: []ocp.BundleConfig{{Name: "system", Requirements: ocp.ToRequirements([]string{"system"})}},
+		}, nil).Once()
+		ocpClient.On("DeleteSource", mock.Anything, "library").Return(nil).Once()
+
+		_, err := reconciler.reconcileDeletion(context.Background(), logr.Discard(), library)
+		gomega.Ω(err).NotTo(gomega.HaveOccurred())
+		gomega.Ω(finalizer.IsSet(library)).To(gomega.BeFalse())
+		ocpClient.AssertExpectations(ginkgo.GinkgoT())
+	})
+
+	ginkgo.It("keeps the source while bundles require it", func() {
+		ocpClient.On("ListBundles", mock.Anything, "").Return(&ocp.ListBundlesResponse{
+			Bundles: []ocp.BundleConfig{
+				{Name: "selected", Requirements: ocp.ToRequirements([]string{"selected", "library"})},
+				{Name: "required", Requirements: ocp.ToRequirements([]string{"required", "library"})},
+			},
+		}, nil).Once()
+
+		_, err := reconciler.reconcileDeletion(context.Background(), logr.Discard(), library)
+		gomega.Ω(err).To(gomega.MatchError(gomega.ContainSubstring("required by bundles: required, selected")))
+		var rerr *ctrlerr.ReconcilerErr
+		gomega.Ω(errors.As(err, &rerr)).To(gomega.BeTrue())
+		gomega.Ω(rerr.Event).To(gomega.Equal(string(styrav1alpha1.EventErrorSourceInUse)))
+		gomega.Ω(finalizer.IsSet(library)).To(gomega.BeTrue())
+		ocpClient.AssertNotCalled(ginkgo.GinkgoT(), "DeleteSource", mock.Anything, mock.Anything)
+	})
+})
+
+var _ = ginkgo.Describe("setLibraryStatusError", func() {
+	ginkgo.It("does not mark the generation as observed", func() {
+		library := &styrav1alpha1.Library{
+			ObjectMeta: metav1.ObjectMeta{Name: "library", Generation: 2},
+			Status:     styrav1alpha1.LibraryStatus{Ready: true, ObservedGeneration: 1},
+		}
+
+		(&LibraryReconciler{}).setLibraryStatusError(library, errors.New("failed"))
+		gomega.Ω(library.Status.Ready).To(gomega.BeFalse())
+		gomega.Ω(library.Status.Phase).To(gomega.Equal(styrav1alpha1.LibraryPhaseFailed))
+		gomega.Ω(library.Status.FailureMessage).To(gomega.Equal("failed"))
+		gomega.Ω(library.Status.ObservedGeneration).To(gomega.Equal(int64(1)))
+	})
+})
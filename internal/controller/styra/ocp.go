@@ -0,0 +1,544 @@
+/*
+Copyright (C) 2025 Bankdata (bankdata@bankdata.dk)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package styra
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/url"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/go-logr/logr"
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	configv2alpha2 "github.com/bankdata/styra-controller/api/config/v2alpha2"
+	"github.com/bankdata/styra-controller/api/styra/v1beta1"
+	ctrlerr "github.com/bankdata/styra-controller/internal/errors"
+	"github.com/bankdata/styra-controller/pkg/httperror"
+	"github.com/bankdata/styra-controller/pkg/ocp"
+	"github.com/bankdata/styra-controller/pkg/ptr"
+)
+
+const (
+	// gcpStorageReadOnlyScope is the OAuth2 scope OPAs request when fetching
+	// bundles from Google Cloud Storage.
+	gcpStorageReadOnlyScope = "https://www.googleapis.com/auth/devstorage.read_only"
+
+	// azureStorageResource is the resource OPAs request managed identity tokens
+	// for when fetching bundles from Azure Blob Storage.
+	azureStorageResource = "https://storage.azure.com/"
+
+	// azureStorageAPIVersion is the Azure Blob Storage API version OPAs use.
+	// Bearer tokens are only accepted from version 2017-11-09.
+	azureStorageAPIVersion = "2020-04-08"
+)
+
+// datasourceSourceID returns the ID of the OCP source backing a datasource
+// mounted at the given path.
+func datasourceSourceID(path string) string {
+	return strings.ToLower(strings.ReplaceAll(path, "/", "-"))
+}
+
+// datasourceSecretID returns the ID of the secret in OCP holding the
+// credentials of the datasource source with the given ID.
+func datasourceSecretID(datasourceID string) string {
+	return datasourceID + "-credentials"
+}
+
+// datasourceSource returns the source backing a datasource with a type. The
+// credentials of the datasource are referenced by secretID when it is set.
+func datasourceSource(id string, datasource v1beta1.Datasource, secretID string) (*ocp.PutSourceRequest, error) {
+	ds := ocp.Datasource{
+		Name:           id,
+		Path:           datasource.Path,
+		Type:           datasource.Type,
+		TransformQuery: datasource.TransformQuery,
+	}
+	if datasource.Config != nil && len(datasource.Config.Raw) > 0 {
+		if err := json.Unmarshal(datasource.Config.Raw, &ds.Config); err != nil {
+			return nil, errors.Wrap(err, "could not unmarshal datasource config")
+		}
+	}
+	if secretID != "" {
+		ds.Credentials = &ocp.SecretRef{Name: secretID}
+	}
+	return &ocp.PutSourceRequest{
+		Name:        id,
+		Datasources: []ocp.Datasource{ds},
+	}, nil
+}
+
+// createSourceIfNotExists creates an empty source in OCP unless a source with
+// the given ID already exists. The returned bool reports whether the source
+// was created.
+func createSourceIfNotExists(
+	ctx context.Context,
+	log logr.Logger,
+	ocpClient ocp.ClientInterface,
+	id string) (bool, error) {
+	_, err := ocpClient.GetSource(ctx, id)
+	if err == nil {
+		log.Info("Source already exists", "source", id)
+		return false, nil
+	}
+
+	var httpErr *httperror.HTTPError
+	if errors.As(err, &httpErr) {
+		if httpErr.StatusCode != http.StatusNotFound {
+			return false, ctrlerr.Wrap(err, "GetSource in createSourceIfNotExists failed")
+		}
+	} else {
+		return false, ctrlerr.Wrap(err, "GetSource in createSourceIfNotExists failed")
+	}
+
+	log.Info("Creating source", "source", id)
+	_, err = ocpClient.PutSource(ctx, id, &ocp.PutSourceRequest{
+		Name: id,
+	})
+	if err != nil {
+		return false, ctrlerr.Wrap(err, "PutSource in createSourceIfNotExists failed")
+	}
+	log.Info("Source created", "source", id)
+
+	return true, nil
+}
+
+// embeddedFilePath returns the path of a file embedded in a source, given the
+// directory and name of the file. Paths must be relative and stay within the
+// source.
+func embeddedFilePath(dir, name string) (string, error) {
+	p := path.Clean(path.Join(dir, name))
+	if name == "" || path.IsAbs(p) || p == "." || p == ".." || strings.HasPrefix(p, "../") {
+		return "", errors.Errorf("invalid file path %q", path.Join(dir, name))
+	}
+	return p, nil
+}
+
+// filesRevision returns a hash of the embedded files of a source, which
+// changes whenever a file is added, removed or changed.
+func filesRevision(files map[string]string) string {
+	h := sha256.New()
+	for _, name := range slices.Sorted(maps.Keys(files)) {
+		fmt.Fprintf(h, "%s\x00%s\x00", name, files[name])
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// specHash returns a hash of the spec of a System, which changes whenever the
+// spec is changed.
+func specHash(spec *v1beta1.SystemSpec) (string, error) {
+	data, err := json.Marshal(spec)
+	if err != nil {
+		return "", errors.Wrap(err, "could not marshal spec")
+	}
+	h := sha256.Sum256(data)
+	return hex.EncodeToString(h[:]), nil
+}
+
+// gitFilePatterns returns the glob patterns for the files included from and
+// excluded from a git source. Patterns set on the resource take precedence
+// over the defaults from the controller configuration, which in turn default
+// to all Rego files except tests.
+func gitFilePatterns(config *configv2alpha2.ProjectConfig, included, excluded []string) ([]string, []string) {
+	defaultIncluded := []string{"*.rego"}
+	defaultExcluded := []string{"*_test.rego"}
+	if config.OPAControlPlaneConfig != nil && config.OPAControlPlaneConfig.SourceFiles != nil {
+		defaultIncluded = config.OPAControlPlaneConfig.SourceFiles.Included
+		defaultExcluded = config.OPAControlPlaneConfig.SourceFiles.Excluded
+	}
+	if len(included) == 0 {
+		included = defaultIncluded
+	}
+	if len(excluded) == 0 {
+		excluded = defaultExcluded
+	}
+	return slices.Clone(included), slices.Clone(excluded)
+}
+
+// bundleExcludedFiles returns the glob patterns for the files excluded from
+// the bundle of the System.
+func bundleExcludedFiles(config *configv2alpha2.ProjectConfig, system *v1beta1.System) []string {
+	if len(system.Spec.BundleExcludedFiles) > 0 {
+		return slices.Clone(system.Spec.BundleExcludedFiles)
+	}
+	if config.OPAControlPlaneConfig != nil {
+		return slices.Clone(config.OPAControlPlaneConfig.BundleExcludedFiles)
+	}
+	return nil
+}
+
+// systemRequirements returns the requirements of the System followed by the
+// sources of the selected Libraries and the default requirements which are not
+// overridden by the System.
+func systemRequirements(system *v1beta1.System, librarySources, defaultRequirements []string) []ocp.Requirement {
+	requirements := make([]ocp.Requirement, 0,
+		len(system.Spec.Requirements)+len(librarySources)+len(defaultRequirements))
+	sources := sets.New[string]()
+	for _, requirement := range system.Spec.Requirements {
+		r := ocp.NewRequirement(requirement.Source)
+		if requirement.Commit != "" {
+			r.Git.Commit = ptr.String(requirement.Commit)
+		}
+		requirements = append(requirements, r)
+		sources.Insert(requirement.Source)
+	}
+	for _, source := range append(slices.Clone(librarySources), defaultRequirements...) {
+		if !sources.Has(source) {
+			requirements = append(requirements, ocp.NewRequirement(source))
+			sources.Insert(source)
+		}
+	}
+	return requirements
+}
+
+// bundleObjectKey returns the key of the bundle of a System in object storage.
+func bundleObjectKey(uniqueName string) string {
+	return fmt.Sprintf("bundles/%s/bundle.tar.gz", uniqueName)
+}
+
+// systemBundleStorage describes where the bundle of a System is stored.
+type systemBundleStorage struct {
+	// objectStorage is the object storage configuration with the overrides
+	// from the System applied.
+	objectStorage *configv2alpha2.BundleObjectStorage
+
+	// key is the key of the bundle in the object storage.
+	key string
+
+	// bundleServerURL is the URL OPA downloads the bundle from. It is empty
+	// when the System does not override the bundle storage.
+	bundleServerURL string
+}
+
+// resolveSystemBundleStorage applies the bundle storage override of the System
+// to the object storage from the controller configuration. The override must
+// match an entry in the bundle storage allowlist.
+func resolveSystemBundleStorage(
+	config *configv2alpha2.ProjectConfig,
+	system *v1beta1.System,
+	uniqueName string,
+) (systemBundleStorage, error) {
+	var storage *configv2alpha2.BundleObjectStorage
+	if config.OPAControlPlaneConfig != nil {
+		storage = config.OPAControlPlaneConfig.BundleObjectStorage
+	}
+
+	override := system.Spec.BundleStorage
+	if override == nil {
+		return systemBundleStorage{objectStorage: storage, key: bundleObjectKey(uniqueName)}, nil
+	}
+	if storage == nil {
+		return systemBundleStorage{}, errors.New("no object storage configured")
+	}
+
+	allowed := findAllowedBundleStorage(config.OPAControlPlaneConfig.BundleStorageAllowlist, system)
+	if allowed == nil {
+		return systemBundleStorage{}, errors.Errorf(
+			"bucket %s is not allowed for systems in namespace %s", override.Bucket, system.Namespace)
+	}
+	if override.Credentials != "" && !slices.Contains(allowed.Credentials, override.Credentials) {
+		return systemBundleStorage{}, errors.Errorf(
+			"credentials %s are not allowed for bucket %s", override.Credentials, override.Bucket)
+	}
+
+	objectStorage := storage.DeepCopy()
+	switch {
+	case objectStorage.S3 != nil:
+		objectStorage.S3.Bucket = override.Bucket
+		if override.Region != "" {
+			objectStorage.S3.Region = override.Region
+		}
+		if override.Credentials != "" {
+			objectStorage.S3.OCPConfigSecretName = override.Credentials
+		}
+	case objectStorage.GCP != nil:
+		objectStorage.GCP.Bucket = override.Bucket
+		if override.Credentials != "" {
+			objectStorage.GCP.OCPConfigSecretName = override.Credentials
+		}
+	case objectStorage.Azure != nil:
+		objectStorage.Azure.Container = override.Bucket
+		if override.Credentials != "" {
+			objectStorage.Azure.OCPConfigSecretName = override.Credentials
+		}
+	default:
+		return systemBundleStorage{}, errors.New("bundle storage can only be overridden for s3, gcp and azure object storage")
+	}
+
+	bundleServerURL := allowed.BundleServerURL
+	if bundleServerURL == "" && objectStorage.S3 != nil && objectStorage.S3.Region != storage.S3.Region {
+		// The OPA bundle server URL points at the region from the controller
+		// configuration, so OPAs could not download bundles from the bucket.
+		return systemBundleStorage{}, errors.Errorf(
+			"region %s can only be used for bucket %s when the allowlist sets its bundleServerUrl",
+			override.Region, override.Bucket)
+	}
+	if bundleServerURL == "" {
+		// For s3 the bundle server path is the bucket, so it is replaced by the
+		// bucket from the override. The gcp and azure bundle resources include
+		// the bucket or container, so the path is kept like without an override.
+		bundleServer := configv2alpha2.OPABundleServer{}
+		if config.OPA.BundleServer != nil {
+			bundleServer = *config.OPA.BundleServer
+		}
+		if objectStorage.S3 != nil {
+			bundleServer.Path = override.Bucket
+		}
+		u, err := opaBundleServerURL(bundleServer)
+		if err != nil {
+			return systemBundleStorage{}, errors.Wrap(err, "invalid OPA bundle server URL")
+		}
+		bundleServerURL = u
+	}
+
+	return systemBundleStorage{
+		objectStorage:   objectStorage,
+		key:             path.Join(override.KeyPrefix, bundleObjectKey(uniqueName)),
+		bundleServerURL: bundleServerURL,
+	}, nil
+}
+
+// opaBundleServerURL returns the URL OPA downloads bundles from, which is the
+// bundle server URL with its path appended.
+func opaBundleServerURL(bundleServer configv2alpha2.OPABundleServer) (string, error) {
+	return url.JoinPath(bundleServer.URL, bundleServer.Path)
+}
+
+// findAllowedBundleStorage returns the entry in the allowlist which allows the
+// System to store its bundle in the bucket from spec.bundleStorage, or nil if
+// there is none.
+func findAllowedBundleStorage(
+	allowlist []configv2alpha2.AllowedBundleStorage,
+	system *v1beta1.System,
+) *configv2alpha2.AllowedBundleStorage {
+	for i := range allowlist {
+		allowed := &allowlist[i]
+		if allowed.Bucket != system.Spec.BundleStorage.Bucket {
+			continue
+		}
+		if len(allowed.Namespaces) == 0 {
+			return allowed
+		}
+		for _, pattern := range allowed.Namespaces {
+			if matched, _ := filepath.Match(pattern, system.Namespace); matched {
+				return allowed
+			}
+		}
+	}
+	return nil
+}
+
+// bundleObjectStorage returns the object storage OCP should write the bundle
+// with the given key to. Exactly one backend must be configured.
+func bundleObjectStorage(
+	storage *configv2alpha2.BundleObjectStorage,
+	key string,
+) (ocp.ObjectStorage, error) {
+	if storage == nil {
+		return ocp.ObjectStorage{}, errors.New("no object storage configured")
+	}
+
+	var objectStorage ocp.ObjectStorage
+	configured := 0
+
+	if storage.S3 != nil {
+		configured++
+		objectStorage.AmazonS3 = &ocp.AmazonS3{
+			Bucket:      storage.S3.Bucket,
+			Key:         key,
+			Region:      storage.S3.Region,
+			URL:         storage.S3.URL,
+			Credentials: storage.S3.OCPConfigSecretName,
+		}
+	}
+	if storage.GCP != nil {
+		configured++
+		objectStorage.GCPCloudStorage = &ocp.GCPCloudStorage{
+			Project:     storage.GCP.Project,
+			Bucket:      storage.GCP.Bucket,
+			Object:      key,
+			Credentials: storage.GCP.OCPConfigSecretName,
+		}
+	}
+	if storage.Azure != nil {
+		configured++
+		objectStorage.AzureBlobStorage = &ocp.AzureBlobStorage{
+			AccountURL:  storage.Azure.AccountURL,
+			Container:   storage.Azure.Container,
+			Path:        key,
+			Credentials: storage.Azure.OCPConfigSecretName,
+		}
+	}
+	if storage.FileSystem != nil {
+		configured++
+		objectStorage.FileSystemStorage = &ocp.FileSystemStorage{
+			Path: path.Join(storage.FileSystem.Path, key),
+		}
+	}
+
+	if configured == 0 {
+		return ocp.ObjectStorage{}, errors.New("no object storage configured")
+	}
+	if configured > 1 {
+		return ocp.ObjectStorage{}, errors.New("more than one object storage configured")
+	}
+	return objectStorage, nil
+}
+
+// opaBundleService returns the service and resource OPA uses to download the
+// bundle with the given key from the object storage. A token path in the
+// bundle server configuration takes precedence over the credentials of the
+// object storage.
+//
+// For s3 the bucket is part of the bundle URL. For gcp the resource is the
+// object in the bucket in the JSON API, so the bundle URL is the storage
+// endpoint, e.g. https://storage.googleapis.com. For azure the resource is
+// the blob in the container, so the bundle URL is the account URL. Bundles on
+// the filesystem are read from a file:// resource and need no service.
+func opaBundleService(
+	storage *configv2alpha2.BundleObjectStorage,
+	bundleServer configv2alpha2.OPABundleServer,
+	bundleURL string,
+	key string,
+) (*ocp.OPAServiceConfig, string) {
+	if storage != nil && storage.FileSystem != nil {
+		return nil, "file://" + path.Join(storage.FileSystem.Path, key)
+	}
+
+	service := &ocp.OPAServiceConfig{
+		Name: bundleServer.Name,
+		URL:  bundleURL,
+	}
+	resource := key
+
+	switch {
+	case storage != nil && storage.GCP != nil:
+		resource = gcpObjectResource(storage.GCP.Bucket, key)
+		service.Credentials = &ocp.ServiceCredentials{
+			GCPMetadata: &ocp.GCPMetadata{
+				Scopes: []string{gcpStorageReadOnlyScope},
+			},
+		}
+	case storage != nil && storage.Azure != nil:
+		resource = path.Join(storage.Azure.Container, key)
+		service.Credentials = &ocp.ServiceCredentials{
+			AzureManagedIdentity: &ocp.AzureManagedIdentity{
+				Resource: azureStorageResource,
+				ClientID: storage.Azure.ManagedIdentityClientID,
+			},
+		}
+		service.Headers = map[string]string{"x-ms-version": azureStorageAPIVersion}
+	default:
+		service.Credentials = &ocp.ServiceCredentials{
+			S3: &ocp.S3Signing{
+				S3EnvironmentCredentials: map[string]ocp.EmptyStruct{},
+			},
+		}
+	}
+
+	if bundleServer.TokenPath != "" {
+		service.Credentials = &ocp.ServiceCredentials{
+			Bearer: &ocp.Bearer{
+				TokenPath: bundleServer.TokenPath,
+			},
+		}
+	}
+
+	return service, resource
+}
+
+// gcpObjectResource returns the resource for downloading the object with the
+// given name from the bucket through the Cloud Storage JSON API. The object
+// name is escaped as it must be a single path segment.
+func gcpObjectResource(bucket string, object string) string {
+	return fmt.Sprintf("storage/v1/b/%s/o/%s?alt=media", url.PathEscape(bucket), url.PathEscape(object))
+}
+
+// sourceUpToDate reports whether the source with the given ID in OCP matches
+// the desired source. A source which does not exist in OCP is not up to date.
+func sourceUpToDate(
+	ctx context.Context,
+	ocpClient ocp.ClientInterface,
+	id string,
+	desired *ocp.PutSourceRequest,
+) (bool, error) {
+	res, err := ocpClient.GetSource(ctx, id)
+	if err != nil {
+		if isNotFound(err) {
+			return false, nil
+		}
+		return false, errors.Wrap(err, "could not get source from OCP")
+	}
+
+	var expected ocp.SourceConfig
+	if err := normalizeJSON(desired, &expected); err != nil {
+		return false, err
+	}
+	actual := *res.Source
+	if actual.Name == "" {
+		actual.Name = expected.Name
+	}
+	return equality.Semantic.DeepEqual(expected, actual), nil
+}
+
+// bundleUpToDate reports whether the bundle in OCP matches the desired bundle.
+// A bundle which does not exist in OCP is not up to date.
+func bundleUpToDate(ctx context.Context, ocpClient ocp.ClientInterface, desired *ocp.PutBundleRequest) (bool, error) {
+	res, err := ocpClient.GetBundle(ctx, desired.Name)
+	if err != nil {
+		if isNotFound(err) {
+			return false, nil
+		}
+		return false, errors.Wrap(err, "could not get bundle from OCP")
+	}
+
+	var expected ocp.BundleConfig
+	if err := normalizeJSON(desired, &expected); err != nil {
+		return false, err
+	}
+	expected.Name = desired.Name
+	return equality.Semantic.DeepEqual(expected, *res.Bundle), nil
+}
+
+// normalizeJSON converts in to out through its JSON representation, so that
+// desired state can be compared with what is read back from the OCP API.
+func normalizeJSON(in interface{}, out interface{}) error {
+	bs, err := json.Marshal(in)
+	if err != nil {
+		return errors.Wrap(err, "could not marshal desired state")
+	}
+	if err := json.Unmarshal(bs, out); err != nil {
+		return errors.Wrap(err, "could not unmarshal desired state")
+	}
+	return nil
+}
+
+// isNotFound returns whether err is a 404 from the OCP API.
+func isNotFound(err error) bool {
+	var httpErr *httperror.HTTPError
+	return errors.As(err, &httpErr) && httpErr.StatusCode == http.StatusNotFound
+}
@@ -0,0 +1,811 @@
+/*
+Copyright (C) 2025 Bankdata (bankdata@bankdata.dk)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package styra
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+
+	"github.com/go-logr/logr"
+	ginkgo "github.com/onsi/ginkgo/v2"
+	gomega "github.com/onsi/gomega"
+	"github.com/stretchr/testify/mock"
+	"gopkg.in/yaml.v2"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	configv2alpha2 "github.com/bankdata/styra-controller/api/config/v2alpha2"
+	"github.com/bankdata/styra-controller/api/styra/v1beta1"
+	"github.com/bankdata/styra-controller/internal/k8sconv"
+	"github.com/bankdata/styra-controller/internal/labels"
+	"github.com/bankdata/styra-controller/pkg/httperror"
+	"github.com/bankdata/styra-controller/pkg/ocp"
+	"github.com/bankdata/styra-controller/pkg/ocp/mocks"
+	"github.com/bankdata/styra-controller/pkg/ptr"
+)
+
+var _ = ginkgo.DescribeTable("datasourceSourceID",
+	func(path string, expected string) {
+		gomega.Ω(datasourceSourceID(path)).To(gomega.Equal(expected))
+	},
+	ginkgo.Entry("single segment", "datasource", "datasource"),
+	ginkgo.Entry("nested path", "path/to/datasource", "path-to-datasource"),
+	ginkgo.Entry("mixed case", "Path/To/DataSource", "path-to-datasource"),
+)
+
+var _ = ginkgo.DescribeTable("embeddedFilePath",
+	func(dir, name, expected string, expectErr bool) {
+		p, err := embeddedFilePath(dir, name)
+		if expectErr {
+			gomega.Ω(err).To(gomega.HaveOccurred())
+			return
+		}
+		gomega.Ω(err).NotTo(gomega.HaveOccurred())
+		gomega.Ω(p).To(gomega.Equal(expected))
+	},
+	ginkgo.Entry("file in root", "", "main.rego", "main.rego", false),
+	ginkgo.Entry("file in directory", "policy", "main.rego", "policy/main.rego", false),
+	ginkgo.Entry("cleaned path", "policy/", "./rules/../main.rego", "policy/main.rego", false),
+	ginkgo.Entry("absolute path", "", "/main.rego", "", true),
+	ginkgo.Entry("outside the source", "policy", "../../main.rego", "", true),
+	ginkgo.Entry("empty name", "policy", "", "", true),
+)
+
+var _ = ginkgo.Describe("filesRevision", func() {
+	ginkgo.It("changes when the files change", func() {
+		files := map[string]string{"a.rego": "package a", "b.rego": "package b"}
+		revision := filesRevision(files)
+		gomega.Ω(filesRevision(map[string]string{"b.rego": "package b", "a.rego": "package a"})).
+			To(gomega.Equal(revision))
+		gomega.Ω(filesRevision(map[string]string{"a.rego": "package a"})).NotTo(gomega.Equal(revision))
+		gomega.Ω(filesRevision(map[string]string{"a.rego": "package a", "b.rego": "package c"})).
+			NotTo(gomega.Equal(revision))
+	})
+})
+
+var _ = ginkgo.Describe("specHash", func() {
+	ginkgo.It("changes when the spec changes", func() {
+		spec := &v1beta1.SystemSpec{Files: map[string]string{"a.rego": "package a"}}
+		hash, err := specHash(spec)
+		gomega.Ω(err).NotTo(gomega.HaveOccurred())
+		gomega.Ω(hash).To(gomega.HaveLen(64))
+		gomega.Ω(specHash(spec.DeepCopy())).To(gomega.Equal(hash))
+
+		spec.Files["a.rego"] = "package b"
+		gomega.Ω(specHash(spec)).NotTo(gomega.Equal(hash))
+	})
+})
+
+var _ = ginkgo.DescribeTable("gitFilePatterns",
+	func(sourceFiles *configv2alpha2.FilePatterns, included, excluded, expIncluded, expExcluded []string) {
+		config := &configv2alpha2.ProjectConfig{
+			OPAControlPlaneConfig: &configv2alpha2.OPAControlPlaneConfig{SourceFiles: sourceFiles},
+		}
+		actualIncluded, actualExcluded := gitFilePatterns(config, included, excluded)
+		gomega.Ω(actualIncluded).To(gomega.Equal(expIncluded))
+		gomega.Ω(actualExcluded).To(gomega.Equal(expExcluded))
+	},
+	ginkgo.Entry("built-in defaults", nil, nil, nil,
+		[]string{"*.rego"}, []string{"*_test.rego"}),
+	ginkgo.Entry("configured defaults",
+		&configv2alpha2.FilePatterns{Included: []string{"*.rego", "*.json"}, Excluded: []string{"*_test.rego"}},
+		nil, nil,
+		[]string{"*.rego", "*.json"}, []string{"*_test.rego"}),
+	ginkgo.Entry("resource patterns take precedence",
+		&configv2alpha2.FilePatterns{Included: []string{"*.rego", "*.json"}},
+		[]string{"*.rego", ".manifest"}, []string{"vendor/*"},
+		[]string{"*.rego", ".manifest"}, []string{"vendor/*"}),
+)
+
+var _ = ginkgo.DescribeTable("bundleExcludedFiles",
+	func(defaults, systemExcluded, expected []string) {
+		config := &configv2alpha2.ProjectConfig{
+			OPAControlPlaneConfig: &configv2alpha2.OPAControlPlaneConfig{BundleExcludedFiles: defaults},
+		}
+		system := &v1beta1.System{Spec: v1beta1.SystemSpec{BundleExcludedFiles: systemExcluded}}
+		gomega.Ω(bundleExcludedFiles(config, system)).To(gomega.Equal(expected))
+	},
+	ginkgo.Entry("not set", nil, nil, nil),
+	ginkgo.Entry("configured default", []string{"*.md"}, nil, []string{"*.md"}),
+	ginkgo.Entry("system takes precedence", []string{"*.md"}, []string{"docs/*"}, []string{"docs/*"}),
+)
+
+var _ = ginkgo.Describe("systemRequirements", func() {
+	ginkgo.It("adds the default requirements which are not overridden", func() {
+		system := &v1beta1.System{Spec: v1beta1.SystemSpec{
+			Requirements: []v1beta1.Requirement{
+				{Source: "pinned-library", Commit: "f37cc9d87251921cbe49349235d9b5305c833769"},
+				{Source: "library"},
+			},
+		}}
+
+		requirements := systemRequirements(system,
+			[]string{"library", "selected-library", "base-library"},
+			[]string{"base-library", "pinned-library"})
+		gomega.Ω(requirements).To(gomega.Equal([]ocp.Requirement{
+			{
+				Source: "pinned-library",
+				Git:    ocp.GitRequirement{Commit: ptr.String("f37cc9d87251921cbe49349235d9b5305c833769")},
+			},
+			{Source: "library"},
+			{Source: "selected-library"},
+			{Source: "base-library"},
+		}))
+	})
+
+	ginkgo.It("returns the default requirements when the system has none", func() {
+		gomega.Ω(systemRequirements(&v1beta1.System{}, nil, []string{"base-library"})).
+			To(gomega.Equal([]ocp.Requirement{{Source: "base-library"}}))
+	})
+})
+
+var _ = ginkgo.Describe("datasourceSource", func() {
+	ginkgo.It("configures the datasource of the source", func() {
+		source, err := datasourceSource("path-to-datasource", v1beta1.Datasource{
+			Path:           "path/to/datasource",
+			Type:           "http",
+			Config:         &runtime.RawExtension{Raw: []byte(`{"url":"https://example.com/data.json"}`)},
+			TransformQuery: "input.items",
+		}, "path-to-datasource-credentials")
+		gomega.Ω(err).NotTo(gomega.HaveOccurred())
+		gomega.Ω(source).To(gomega.Equal(&ocp.PutSourceRequest{
+			Name: "path-to-datasource",
+			Datasources: []ocp.Datasource{{
+				Name:           "path-to-datasource",
+				Path:           "path/to/datasource",
+				Type:           "http",
+				TransformQuery: "input.items",
+				Config:         map[string]interface{}{"url": "https://example.com/data.json"},
+				Credentials:    &ocp.SecretRef{Name: "path-to-datasource-credentials"},
+			}},
+		}))
+
+		bs, err := json.Marshal(source.Datasources[0])
+		gomega.Ω(err).NotTo(gomega.HaveOccurred())
+		gomega.Ω(string(bs)).To(gomega.MatchJSON(`{
+			"name": "path-to-datasource",
+			"path": "path/to/datasource",
+			"type": "http",
+			"transform_query": "input.items",
+			"config": {"url": "https://example.com/data.json"},
+			"credentials": "path-to-datasource-credentials"
+		}`))
+	})
+
+	ginkgo.It("omits credentials and config when they are not set", func() {
+		source, err := datasourceSource("ds", v1beta1.Datasource{Path: "ds", Type: "http"}, "")
+		gomega.Ω(err).NotTo(gomega.HaveOccurred())
+		gomega.Ω(source.Datasources[0].Credentials).To(gomega.BeNil())
+		gomega.Ω(source.Datasources[0].Config).To(gomega.BeNil())
+	})
+
+	ginkgo.It("fails on invalid config", func() {
+		_, err := datasourceSource("ds", v1beta1.Datasource{
+			Path:   "ds",
+			Type:   "http",
+			Config: &runtime.RawExtension{Raw: []byte(`[]`)},
+		}, "")
+		gomega.Ω(err).To(gomega.HaveOccurred())
+	})
+})
+
+var _ = ginkgo.DescribeTable("bundleObjectStorage",
+	func(storage *configv2alpha2.BundleObjectStorage, expected ocp.ObjectStorage, expectErr bool) {
+		objectStorage, err := bundleObjectStorage(storage, bundleObjectKey("unique"))
+		if expectErr {
+			gomega.Ω(err).Should(gomega.HaveOccurred())
+			return
+		}
+		gomega.Ω(err).ShouldNot(gomega.HaveOccurred())
+		gomega.Ω(objectStorage).To(gomega.Equal(expected))
+	},
+	ginkgo.Entry("s3",
+		&configv2alpha2.BundleObjectStorage{
+			S3: &configv2alpha2.S3ObjectStorage{
+				Bucket:              "bucket",
+				Region:              "eu-west-1",
+				URL:                 "https://s3.example.com",
+				OCPConfigSecretName: "s3-creds",
+			},
+		},
+		ocp.ObjectStorage{
+			AmazonS3: &ocp.AmazonS3{
+				Bucket:      "bucket",
+				Key:         "bundles/unique/bundle.tar.gz",
+				Region:      "eu-west-1",
+				URL:         "https://s3.example.com",
+				Credentials: "s3-creds",
+			},
+		},
+		false,
+	),
+	ginkgo.Entry("gcp",
+		&configv2alpha2.BundleObjectStorage{
+			GCP: &configv2alpha2.GCPObjectStorage{
+				Project:             "project",
+				Bucket:              "bucket",
+				OCPConfigSecretName: "gcp-creds",
+			},
+		},
+		ocp.ObjectStorage{
+			GCPCloudStorage: &ocp.GCPCloudStorage{
+				Project:     "project",
+				Bucket:      "bucket",
+				Object:      "bundles/unique/bundle.tar.gz",
+				Credentials: "gcp-creds",
+			},
+		},
+		false,
+	),
+	ginkgo.Entry("azure",
+		&configv2alpha2.BundleObjectStorage{
+			Azure: &configv2alpha2.AzureObjectStorage{
+				AccountURL:          "https://account.blob.core.windows.net",
+				Container:           "bundles",
+				OCPConfigSecretName: "azure-creds",
+			},
+		},
+		ocp.ObjectStorage{
+			AzureBlobStorage: &ocp.AzureBlobStorage{
+				AccountURL:  "https://account.blob.core.windows.net",
+				Container:   "bundles",
+				Path:        "bundles/unique/bundle.tar.gz",
+				Credentials: "azure-creds",
+			},
+		},
+		false,
+	),
+	ginkgo.Entry("filesystem",
+		&configv2alpha2.BundleObjectStorage{
+			FileSystem: &configv2alpha2.FileSystemObjectStorage{Path: "/data"},
+		},
+		ocp.ObjectStorage{
+			FileSystemStorage: &ocp.FileSystemStorage{Path: "/data/bundles/unique/bundle.tar.gz"},
+		},
+		false,
+	),
+	ginkgo.Entry("nil storage", nil, ocp.ObjectStorage{}, true),
+	ginkgo.Entry("no backend", &configv2alpha2.BundleObjectStorage{}, ocp.ObjectStorage{}, true),
+	ginkgo.Entry("multiple backends",
+		&configv2alpha2.BundleObjectStorage{
+			S3:         &configv2alpha2.S3ObjectStorage{Bucket: "bucket"},
+			FileSystem: &configv2alpha2.FileSystemObjectStorage{Path: "/data"},
+		},
+		ocp.ObjectStorage{},
+		true,
+	),
+)
+
+var _ = ginkgo.DescribeTable("opaBundleService",
+	func(
+		storage *configv2alpha2.BundleObjectStorage,
+		tokenPath string,
+		expectedService *ocp.OPAServiceConfig,
+		expectedResource string,
+	) {
+		bundleServer := configv2alpha2.OPABundleServer{Name: "bundles", TokenPath: tokenPath}
+		service, resource := opaBundleService(storage, bundleServer, "https://bundles.example.com", bundleObjectKey("unique"))
+		gomega.Ω(service).To(gomega.Equal(expectedService))
+		gomega.Ω(resource).To(gomega.Equal(expectedResource))
+	},
+	ginkgo.Entry("s3",
+		&configv2alpha2.BundleObjectStorage{S3: &configv2alpha2.S3ObjectStorage{}},
+		"",
+		&ocp.OPAServiceConfig{
+			Name: "bundles",
+			URL:  "https://bundles.example.com",
+			Credentials: &ocp.ServiceCredentials{
+				S3: &ocp.S3Signing{S3EnvironmentCredentials: map[string]ocp.EmptyStruct{}},
+			},
+		},
+		"bundles/unique/bundle.tar.gz",
+	),
+	ginkgo.Entry("gcp",
+		&configv2alpha2.BundleObjectStorage{GCP: &configv2alpha2.GCPObjectStorage{Bucket: "bucket"}},
+		"",
+		&ocp.OPAServiceConfig{
+			Name: "bundles",
+			URL:  "https://bundles.example.com",
+			Credentials: &ocp.ServiceCredentials{
+				GCPMetadata: &ocp.GCPMetadata{
+					Scopes: []string{"https://www.googleapis.com/auth/devstorage.read_only"},
+				},
+			},
+		},
+		"storage/v1/b/bucket/o/bundles%2Funique%2Fbundle.tar.gz?alt=media",
+	),
+	ginkgo.Entry("azure",
+		&configv2alpha2.BundleObjectStorage{
+			Azure: &configv2alpha2.AzureObjectStorage{Container: "container", ManagedIdentityClientID: "client-id"},
+		},
+		"",
+		&ocp.OPAServiceConfig{
+			Name: "bundles",
+			URL:  "https://bundles.example.com",
+			Credentials: &ocp.ServiceCredentials{
+				AzureManagedIdentity: &ocp.AzureManagedIdentity{
+					Resource: "https://storage.azure.com/",
+					ClientID: "client-id",
+				},
+			},
+			Headers: map[string]string{"x-ms-version": "2020-04-08"},
+		},
+		"container/bundles/unique/bundle.tar.gz",
+	),
+	ginkgo.Entry("filesystem",
+		&configv2alpha2.BundleObjectStorage{
+			FileSystem: &configv2alpha2.FileSystemObjectStorage{Path: "/data"},
+		},
+		"",
+		nil,
+		"file:///data/bundles/unique/bundle.tar.gz",
+	),
+	ginkgo.Entry("token path takes precedence",
+		&configv2alpha2.BundleObjectStorage{GCP: &configv2alpha2.GCPObjectStorage{Bucket: "bucket"}},
+		"/var/run/token",
+		&ocp.OPAServiceConfig{
+			Name: "bundles",
+			URL:  "https://bundles.example.com",
+			Credentials: &ocp.ServiceCredentials{
+				Bearer: &ocp.Bearer{TokenPath: "/var/run/token"},
+			},
+		},
+		"storage/v1/b/bucket/o/bundles%2Funique%2Fbundle.tar.gz?alt=media",
+	),
+)
+
+var _ = ginkgo.DescribeTable("OPA bundle config",
+	func(storage *configv2alpha2.BundleObjectStorage, bundleURL string, expectedYAML string) {
+		service, resource := opaBundleService(
+			storage, configv2alpha2.OPABundleServer{Name: "bundles"}, bundleURL, bundleObjectKey("unique"))
+		cm, err := k8sconv.OPAConfToK8sOPAConfigMapforOCP(ocp.OPAConfig{
+			BundleService:  service,
+			BundleResource: resource,
+			LogService:     &ocp.OPAServiceConfig{Name: "logs", URL: "https://logs.example.com"},
+		}, configv2alpha2.OPAConfig{}, nil, logr.Discard())
+		gomega.Ω(err).ShouldNot(gomega.HaveOccurred())
+
+		var actual, expected map[string]interface{}
+		gomega.Ω(yaml.Unmarshal([]byte(cm.Data["opa-conf.yaml"]), &actual)).To(gomega.Succeed())
+		gomega.Ω(yaml.Unmarshal([]byte(expectedYAML), &expected)).To(gomega.Succeed())
+		gomega.Ω(actual["services"]).To(gomega.Equal(expected["services"]))
+		gomega.Ω(actual["bundles"]).To(gomega.Equal(expected["bundles"]))
+	},
+	ginkgo.Entry("s3",
+		&configv2alpha2.BundleObjectStorage{S3: &configv2alpha2.S3ObjectStorage{Bucket: "bucket"}},
+		"https://s3.eu-west-1.amazonaws.com/bucket",
+		`services:
+- name: bundles
+  url: https://s3.eu-west-1.amazonaws.com/bucket
+  credentials:
+    s3_signing:
+      environment_credentials: {}
+- name: logs
+  url: https://logs.example.com
+bundles:
+  authz:
+    service: bundles
+    resource: bundles/unique/bundle.tar.gz
+`),
+	ginkgo.Entry("gcp",
+		&configv2alpha2.BundleObjectStorage{GCP: &configv2alpha2.GCPObjectStorage{Bucket: "bucket"}},
+		"https://storage.googleapis.com",
+		`services:
+- name: bundles
+  url: https://storage.googleapis.com
+  credentials:
+    gcp_metadata:
+      scopes:
+      - https://www.googleapis.com/auth/devstorage.read_only
+- name: logs
+  url: https://logs.example.com
+bundles:
+  authz:
+    service: bundles
+    resource: storage/v1/b/bucket/o/bundles%2Funique%2Fbundle.tar.gz?alt=media
+`),
+	ginkgo.Entry("azure",
+		&configv2alpha2.BundleObjectStorage{Azure: &configv2alpha2.AzureObjectStorage{Container: "container"}},
+		"https://account.blob.core.windows.net",
+		`services:
+- name: bundles
+  url: https://account.blob.core.windows.net
+  headers:
+    x-ms-version: "2020-04-08"
+  credentials:
+    azure_managed_identity:
+      resource: https://storage.azure.com/
+- name: logs
+  url: https://logs.example.com
+bundles:
+  authz:
+    service: bundles
+    resource: container/bundles/unique/bundle.tar.gz
+`),
+	ginkgo.Entry("filesystem",
+		&configv2alpha2.BundleObjectStorage{FileSystem: &configv2alpha2.FileSystemObjectStorage{Path: "/data"}},
+		"https://bundles.example.com",
+		`services:
+- name: logs
+  url: https://logs.example.com
+bundles:
+  authz:
+    resource: file:///data/bundles/unique/bundle.tar.gz
+`),
+)
+
+var _ = ginkgo.Describe("resolveSystemBundleStorage", func() {
+	var config *configv2alpha2.ProjectConfig
+
+	newSystem := func(namespace string, bundleStorage *v1beta1.BundleStorage) *v1beta1.System {
+		return &v1beta1.System{
+			ObjectMeta: metav1.ObjectMeta{Name: "system", Namespace: namespace},
+			Spec:       v1beta1.SystemSpec{BundleStorage: bundleStorage},
+		}
+	}
+
+	ginkgo.BeforeEach(func() {
+		config = &configv2alpha2.ProjectConfig{
+			OPA: configv2alpha2.OPAConfig{
+				BundleServer: &configv2alpha2.OPABundleServer{URL: "https://s3.example.com"},
+			},
+			OPAControlPlaneConfig: &configv2alpha2.OPAControlPlaneConfig{
+				BundleObjectStorage: &configv2alpha2.BundleObjectStorage{
+					S3: &configv2alpha2.S3ObjectStorage{
+						Bucket:              "shared",
+						Region:              "eu-west-1",
+						URL:                 "https://s3.example.com",
+						OCPConfigSecretName: "shared-creds",
+					},
+				},
+				BundleStorageAllowlist: []configv2alpha2.AllowedBundleStorage{
+					{
+						Bucket:      "regulated",
+						Namespaces:  []string{"team-*"},
+						Credentials: []string{"regulated-creds"},
+					},
+					{
+						Bucket:          "external",
+						BundleServerURL: "https://external.example.com/bundles",
+					},
+				},
+			},
+		}
+	})
+
+	ginkgo.It("uses the controller configuration without an override", func() {
+		storage, err := resolveSystemBundleStorage(config, newSystem("team-a", nil), "unique")
+		gomega.Ω(err).ShouldNot(gomega.HaveOccurred())
+		gomega.Ω(storage).To(gomega.Equal(systemBundleStorage{
+			objectStorage: config.OPAControlPlaneConfig.BundleObjectStorage,
+			key:           "bundles/unique/bundle.tar.gz",
+		}))
+	})
+
+	ginkgo.It("applies an allowed override", func() {
+		system := newSystem("team-a", &v1beta1.BundleStorage{
+			Bucket:      "regulated",
+			KeyPrefix:   "team-a",
+			Credentials: "regulated-creds",
+		})
+		storage, err := resolveSystemBundleStorage(config, system, "unique")
+		gomega.Ω(err).ShouldNot(gomega.HaveOccurred())
+		gomega.Ω(storage).To(gomega.Equal(systemBundleStorage{
+			objectStorage: &configv2alpha2.BundleObjectStorage{
+				S3: &configv2alpha2.S3ObjectStorage{
+					Bucket:              "regulated",
+					Region:              "eu-west-1",
+					URL:                 "https://s3.example.com",
+					OCPConfigSecretName: "regulated-creds",
+				},
+			},
+			key:             "team-a/bundles/unique/bundle.tar.gz",
+			bundleServerURL: "https://s3.example.com/regulated",
+		}))
+		gomega.Ω(config.OPAControlPlaneConfig.BundleObjectStorage.S3.Bucket).To(gomega.Equal("shared"))
+	})
+
+	ginkgo.It("applies a region override with the bundle server URL from the allowlist", func() {
+		storage, err := resolveSystemBundleStorage(
+			config, newSystem("other", &v1beta1.BundleStorage{Bucket: "external", Region: "eu-north-1"}), "unique")
+		gomega.Ω(err).ShouldNot(gomega.HaveOccurred())
+		gomega.Ω(storage.objectStorage.S3.Region).To(gomega.Equal("eu-north-1"))
+		gomega.Ω(storage.bundleServerURL).To(gomega.Equal("https://external.example.com/bundles"))
+	})
+
+	ginkgo.It("allows overriding the region with the configured region", func() {
+		storage, err := resolveSystemBundleStorage(
+			config, newSystem("team-a", &v1beta1.BundleStorage{Bucket: "regulated", Region: "eu-west-1"}), "unique")
+		gomega.Ω(err).ShouldNot(gomega.HaveOccurred())
+		gomega.Ω(storage.bundleServerURL).To(gomega.Equal("https://s3.example.com/regulated"))
+	})
+
+	ginkgo.It("rejects a region override without a bundle server URL in the allowlist", func() {
+		_, err := resolveSystemBundleStorage(
+			config, newSystem("team-a", &v1beta1.BundleStorage{Bucket: "regulated", Region: "eu-north-1"}), "unique")
+		gomega.Ω(err).Should(gomega.MatchError(gomega.ContainSubstring("bundleServerUrl")))
+	})
+
+	ginkgo.It("replaces the bundle server path with the bucket for s3", func() {
+		config.OPA.BundleServer.Path = "/shared"
+		storage, err := resolveSystemBundleStorage(
+			config, newSystem("team-a", &v1beta1.BundleStorage{Bucket: "regulated"}), "unique")
+		gomega.Ω(err).ShouldNot(gomega.HaveOccurred())
+		gomega.Ω(storage.bundleServerURL).To(gomega.Equal("https://s3.example.com/regulated"))
+	})
+
+	ginkgo.It("keeps the bundle server path for gcp", func() {
+		config.OPA.BundleServer.URL = "https://storage.googleapis.com"
+		config.OPA.BundleServer.Path = "/proxy"
+		config.OPAControlPlaneConfig.BundleObjectStorage = &configv2alpha2.BundleObjectStorage{
+			GCP: &configv2alpha2.GCPObjectStorage{Bucket: "shared"},
+		}
+
+		storage, err := resolveSystemBundleStorage(config, newSystem("team-a", nil), "unique")
+		gomega.Ω(err).ShouldNot(gomega.HaveOccurred())
+		defaultURL, err := opaBundleServerURL(*config.OPA.BundleServer)
+		gomega.Ω(err).ShouldNot(gomega.HaveOccurred())
+		gomega.Ω(storage.bundleServerURL).To(gomega.BeEmpty())
+		gomega.Ω(defaultURL).To(gomega.Equal("https://storage.googleapis.com/proxy"))
+
+		storage, err = resolveSystemBundleStorage(
+			config, newSystem("team-a", &v1beta1.BundleStorage{Bucket: "regulated"}), "unique")
+		gomega.Ω(err).ShouldNot(gomega.HaveOccurred())
+		gomega.Ω(storage.objectStorage.GCP.Bucket).To(gomega.Equal("regulated"))
+		gomega.Ω(storage.bundleServerURL).To(gomega.Equal(defaultURL))
+	})
+
+	ginkgo.It("keeps the bundle server path for azure", func() {
+		config.OPA.BundleServer.URL = "https://account.blob.core.windows.net"
+		config.OPA.BundleServer.Path = "/proxy"
+		config.OPAControlPlaneConfig.BundleObjectStorage = &configv2alpha2.BundleObjectStorage{
+			Azure: &configv2alpha2.AzureObjectStorage{Container: "shared"},
+		}
+
+		storage, err := resolveSystemBundleStorage(config, newSystem("team-a", nil), "unique")
+		gomega.Ω(err).ShouldNot(gomega.HaveOccurred())
+		defaultURL, err := opaBundleServerURL(*config.OPA.BundleServer)
+		gomega.Ω(err).ShouldNot(gomega.HaveOccurred())
+		gomega.Ω(storage.bundleServerURL).To(gomega.BeEmpty())
+		gomega.Ω(defaultURL).To(gomega.Equal("https://account.blob.core.windows.net/proxy"))
+
+		storage, err = resolveSystemBundleStorage(
+			config, newSystem("team-a", &v1beta1.BundleStorage{Bucket: "regulated"}), "unique")
+		gomega.Ω(err).ShouldNot(gomega.HaveOccurred())
+		gomega.Ω(storage.objectStorage.Azure.Container).To(gomega.Equal("regulated"))
+		gomega.Ω(storage.bundleServerURL).To(gomega.Equal(defaultURL))
+	})
+
+	ginkgo.It("uses the bundle server URL from the allowlist", func() {
+		storage, err := resolveSystemBundleStorage(
+			config, newSystem("other", &v1beta1.BundleStorage{Bucket: "external"}), "unique")
+		gomega.Ω(err).ShouldNot(gomega.HaveOccurred())
+		gomega.Ω(storage.bundleServerURL).To(gomega.Equal("https://external.example.com/bundles"))
+		gomega.Ω(storage.objectStorage.S3.OCPConfigSecretName).To(gomega.Equal("shared-creds"))
+	})
+
+	ginkgo.It("rejects buckets which are not in the allowlist", func() {
+		_, err := resolveSystemBundleStorage(
+			config, newSystem("team-a", &v1beta1.BundleStorage{Bucket: "unknown"}), "unique")
+		gomega.Ω(err).Should(gomega.HaveOccurred())
+	})
+
+	ginkgo.It("rejects namespaces which are not allowed to use the bucket", func() {
+		_, err := resolveSystemBundleStorage(
+			config, newSystem("other", &v1beta1.BundleStorage{Bucket: "regulated"}), "unique")
+		gomega.Ω(err).Should(gomega.HaveOccurred())
+	})
+
+	ginkgo.It("rejects credentials which are not allowed for the bucket", func() {
+		system := newSystem("team-a", &v1beta1.BundleStorage{Bucket: "regulated", Credentials: "shared-creds"})
+		_, err := resolveSystemBundleStorage(config, system, "unique")
+		gomega.Ω(err).Should(gomega.HaveOccurred())
+	})
+})
+
+var _ = ginkgo.Describe("drift detection against OCP responses", func() {
+	var ocpClient ocp.ClientInterface
+
+	ginkgo.BeforeEach(func() {
+		responses := map[string]string{
+			"/v1/sources/default-system": "get_system_source.json",
+			"/v1/sources/users":          "get_datasource_source.json",
+			"/v1/bundles/default-system": "get_bundle.json",
+		}
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			name, ok := responses[r.URL.Path]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			body, err := os.ReadFile(filepath.Join("testdata", name))
+			if err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			_, _ = w.Write(body)
+		}))
+		ginkgo.DeferCleanup(server.Close)
+		ocpClient = ocp.New(server.URL, "token")
+	})
+
+	ginkgo.It("does not report drift for the system source OCP returns", func() {
+		upToDate, err := sourceUpToDate(context.Background(), ocpClient, "default-system", &ocp.PutSourceRequest{
+			Name: "default-system",
+			Git: &ocp.GitConfig{
+				Repo:          "https://github.com/org/policies.git",
+				Reference:     "refs/heads/main",
+				Path:          "policies",
+				IncludedFiles: []string{"*.rego"},
+				ExcludedFiles: []string{"*_test.rego"},
+				CredentialID:  "default-system-git",
+			},
+			EmbeddedFiles: map[string]string{"system/log/mask.rego": "package system.log\n"},
+		})
+		gomega.Ω(err).ShouldNot(gomega.HaveOccurred())
+		gomega.Ω(upToDate).To(gomega.BeTrue())
+	})
+
+	ginkgo.It("does not report drift for the datasource source OCP returns", func() {
+		desired, err := datasourceSource("users", v1beta1.Datasource{
+			Path: "users",
+			Type: "http",
+			Config: &runtime.RawExtension{
+				Raw: []byte(`{"url": "https://users.example.com", "headers": {"Accept": "application/json"}}`),
+			},
+		}, datasourceSecretID("users"))
+		gomega.Ω(err).ShouldNot(gomega.HaveOccurred())
+
+		upToDate, err := sourceUpToDate(context.Background(), ocpClient, "users", desired)
+		gomega.Ω(err).ShouldNot(gomega.HaveOccurred())
+		gomega.Ω(upToDate).To(gomega.BeTrue())
+	})
+
+	ginkgo.It("does not report drift for the bundle OCP returns", func() {
+		upToDate, err := bundleUpToDate(context.Background(), ocpClient, &ocp.PutBundleRequest{
+			Name:   "default-system",
+			Labels: labels.OCPBundleLabels("", "default", "system"),
+			ObjectStorage: ocp.ObjectStorage{
+				AmazonS3: &ocp.AmazonS3{
+					Bucket:      "bundles",
+					Key:         "bundles/default-system/bundle.tar.gz",
+					Region:      "eu-west-1",
+					Credentials: "s3-credentials",
+				},
+			},
+			Requirements:  ocp.ToRequirements([]string{"default-system", "users"}),
+			Revision:      `$"git-sha:{input.sources["default-system"].git.commit}"`,
+			ExcludedFiles: []string{"*_test.rego"},
+		})
+		gomega.Ω(err).ShouldNot(gomega.HaveOccurred())
+		gomega.Ω(upToDate).To(gomega.BeTrue())
+	})
+})
+
+var _ = ginkgo.Describe("sourceUpToDate", func() {
+	desired := &ocp.PutSourceRequest{
+		Name: "unique",
+		Git: &ocp.GitConfig{
+			Repo:         "https://github.com/bankdata/policies.git",
+			Commit:       "abc",
+			CredentialID: "git",
+		},
+		EmbeddedFiles: map[string]string{"system/log/mask.rego": "package system.log"},
+	}
+
+	ginkgo.It("is up to date when OCP holds the desired source", func() {
+		ocpClient := &mocks.ClientInterface{}
+		ocpClient.On("GetSource", mock.Anything, "unique").Return(&ocp.GetSourceResponse{
+			Source: &ocp.SourceConfig{
+				Git:           *desired.Git,
+				EmbeddedFiles: desired.EmbeddedFiles,
+			},
+		}, nil)
+
+		upToDate, err := sourceUpToDate(context.Background(), ocpClient, "unique", desired)
+		gomega.Ω(err).ShouldNot(gomega.HaveOccurred())
+		gomega.Ω(upToDate).To(gomega.BeTrue())
+	})
+
+	ginkgo.It("is not up to date when the source in OCP differs", func() {
+		ocpClient := &mocks.ClientInterface{}
+		git := *desired.Git
+		git.Commit = "def"
+		ocpClient.On("GetSource", mock.Anything, "unique").Return(&ocp.GetSourceResponse{
+			Source: &ocp.SourceConfig{Name: "unique", Git: git, EmbeddedFiles: desired.EmbeddedFiles},
+		}, nil)
+
+		upToDate, err := sourceUpToDate(context.Background(), ocpClient, "unique", desired)
+		gomega.Ω(err).ShouldNot(gomega.HaveOccurred())
+		gomega.Ω(upToDate).To(gomega.BeFalse())
+	})
+
+	ginkgo.It("is not up to date when the source does not exist in OCP", func() {
+		ocpClient := &mocks.ClientInterface{}
+		ocpClient.On("GetSource", mock.Anything, "unique").
+			Return(nil, httperror.NewHTTPError(http.StatusNotFound, "not found"))
+
+		upToDate, err := sourceUpToDate(context.Background(), ocpClient, "unique", desired)
+		gomega.Ω(err).ShouldNot(gomega.HaveOccurred())
+		gomega.Ω(upToDate).To(gomega.BeFalse())
+	})
+
+	ginkgo.It("returns other errors", func() {
+		ocpClient := &mocks.ClientInterface{}
+		ocpClient.On("GetSource", mock.Anything, "unique").
+			Return(nil, httperror.NewHTTPError(http.StatusInternalServerError, "error"))
+
+		_, err := sourceUpToDate(context.Background(), ocpClient, "unique", desired)
+		gomega.Ω(err).Should(gomega.HaveOccurred())
+	})
+})
+
+var _ = ginkgo.Describe("bundleUpToDate", func() {
+	desired := &ocp.PutBundleRequest{
+		Name: "unique",
+		ObjectStorage: ocp.ObjectStorage{
+			AmazonS3: &ocp.AmazonS3{Bucket: "bucket", Key: "bundles/unique/bundle.tar.gz"},
+		},
+		Requirements: []ocp.Requirement{ocp.NewRequirement("unique")},
+		Revision:     "revision",
+	}
+
+	ginkgo.It("is up to date when OCP holds the desired bundle", func() {
+		ocpClient := &mocks.ClientInterface{}
+		ocpClient.On("GetBundle", mock.Anything, "unique").Return(&ocp.GetBundleResponse{
+			Bundle: &ocp.BundleConfig{
+				Name:          "unique",
+				ObjectStorage: desired.ObjectStorage,
+				Requirements:  desired.Requirements,
+				Revision:      desired.Revision,
+			},
+		}, nil)
+
+		upToDate, err := bundleUpToDate(context.Background(), ocpClient, desired)
+		gomega.Ω(err).ShouldNot(gomega.HaveOccurred())
+		gomega.Ω(upToDate).To(gomega.BeTrue())
+	})
+
+	ginkgo.It("is not up to date when the bundle in OCP differs", func() {
+		ocpClient := &mocks.ClientInterface{}
+		ocpClient.On("GetBundle", mock.Anything, "unique").Return(&ocp.GetBundleResponse{
+			Bundle: &ocp.BundleConfig{
+				Name:          "unique",
+				ObjectStorage: desired.ObjectStorage,
+				Revision:      desired.Revision,
+			},
+		}, nil)
+
+		upToDate, err := bundleUpToDate(context.Background(), ocpClient, desired)
+		gomega.Ω(err).ShouldNot(gomega.HaveOccurred())
+		gomega.Ω(upToDate).To(gomega.BeFalse())
+	})
+
+	ginkgo.It("is not up to date when the bundle does not exist in OCP", func() {
+		ocpClient := &mocks.ClientInterface{}
+		ocpClient.On("GetBundle", mock.Anything, "unique").
+			Return(nil, httperror.NewHTTPError(http.StatusNotFound, "not found"))
+
+		upToDate, err := bundleUpToDate(context.Background(), ocpClient, desired)
+		gomega.Ω(err).ShouldNot(gomega.HaveOccurred())
+		gomega.Ω(upToDate).To(gomega.BeFalse())
+	})
+})
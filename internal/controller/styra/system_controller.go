@@ -19,9 +19,8 @@ package styra
 import (
 	"context"
 	"fmt"
-	"net/http"
-	"net/url"
 	"path/filepath"
+	"slices"
 	"strings"
 	"time"
 
@@ -30,33 +29,52 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 	"gopkg.in/yaml.v2"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sfields "k8s.io/apimachinery/pkg/fields"
+	k8slabels "k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/client-go/tools/events"
+	"k8s.io/client-go/util/workqueue"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 	ctrlpred "sigs.k8s.io/controller-runtime/pkg/predicate"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	configv2alpha2 "github.com/bankdata/styra-controller/api/config/v2alpha2"
+	styrav1alpha1 "github.com/bankdata/styra-controller/api/styra/v1alpha1"
 	"github.com/bankdata/styra-controller/api/styra/v1beta1"
+	"github.com/bankdata/styra-controller/internal/decisionlog"
 	ctrlerr "github.com/bankdata/styra-controller/internal/errors"
 	"github.com/bankdata/styra-controller/internal/fields"
 	"github.com/bankdata/styra-controller/internal/finalizer"
+	"github.com/bankdata/styra-controller/internal/gitcredentials"
+	"github.com/bankdata/styra-controller/internal/giturl"
 	"github.com/bankdata/styra-controller/internal/k8sconv"
 	"github.com/bankdata/styra-controller/internal/labels"
 	"github.com/bankdata/styra-controller/internal/predicate"
 	"github.com/bankdata/styra-controller/internal/webhook"
-	"github.com/bankdata/styra-controller/pkg/httperror"
 	"github.com/bankdata/styra-controller/pkg/ocp"
 )
 
+const (
+	gitCredentialsUsernameKey   = "name"
+	gitCredentialsPasswordKey   = "secret"
+	gitCredentialsPassphraseKey = "passphrase"
+
+	// gitCredentialsFingerprintsKey holds the whitespace separated SSH host
+	// key fingerprints the git server is verified against.
+	gitCredentialsFingerprintsKey = "fingerprints"
+)
+
 const (
 	awsSecretNameKeyID     = "AWS_ACCESS_KEY_ID"
 	awsSecretNameSecretKey = "AWS_SECRET_ACCESS_KEY"
@@ -68,6 +86,7 @@ type SystemReconcilerMetrics struct {
 	ControllerSystemStatusReady *prometheus.GaugeVec
 	ReconcileSegmentTime        *prometheus.HistogramVec
 	ReconcileTime               *prometheus.HistogramVec
+	DriftDetected               *prometheus.CounterVec
 }
 
 // SystemReconciler reconciles a System object
@@ -103,7 +122,7 @@ func (r *SystemReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 	if err := r.APIReader.Get(ctx, req.NamespacedName, &system); err != nil {
 		if k8serrors.IsNotFound(err) {
 			log.Info("Could not find System in kubernetes")
-			r.Metrics.ReconcileTime.WithLabelValues("delete").Observe(time.Since(start).Seconds())
+			r.observeReconcileTime("delete", start)
 			return ctrl.Result{}, nil
 		}
 		return ctrl.Result{}, errors.Wrap(err, "unable to fetch System")
@@ -133,7 +152,7 @@ func (r *SystemReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 			r.updateMetric(req, system.Status.ID, system.Status.Ready, labels.LabelValueControlPlaneOCP)
 		} else {
 			r.deleteMetrics(req)
-			r.Metrics.ReconcileTime.WithLabelValues("delete").Observe(time.Since(start).Seconds())
+			r.observeReconcileTime("delete", start)
 			return res, err
 		}
 	}
@@ -143,12 +162,12 @@ func (r *SystemReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 		r.recordErrorEvent(&system, err)
 		r.setSystemStatusError(&system, err)
 
-		r.Metrics.ReconcileTime.WithLabelValues("error").Observe(time.Since(start).Seconds())
+		r.observeReconcileTime("error", start)
 		if err := r.Status().Update(ctx, &system); err != nil {
 			return res, errors.Wrap(err, "could not set failure status on System")
 		}
 	} else {
-		r.Metrics.ReconcileTime.WithLabelValues("ok").Observe(time.Since(start).Seconds())
+		r.observeReconcileTime("ok", start)
 	}
 	return res, err
 }
@@ -180,6 +199,23 @@ func (r *SystemReconciler) setSystemStatusError(System *v1beta1.System, err erro
 	}
 }
 
+// observeReconcileTime records the duration of a reconciliation with the
+// given result.
+func (r *SystemReconciler) observeReconcileTime(result string, start time.Time) {
+	if r.Metrics == nil || r.Metrics.ReconcileTime == nil {
+		return
+	}
+	r.Metrics.ReconcileTime.WithLabelValues(result).Observe(time.Since(start).Seconds())
+}
+
+// observeSegmentTime records the duration of a segment of a reconciliation.
+func (r *SystemReconciler) observeSegmentTime(segment string, start time.Time) {
+	if r.Metrics == nil || r.Metrics.ReconcileSegmentTime == nil {
+		return
+	}
+	r.Metrics.ReconcileSegmentTime.WithLabelValues(segment).Observe(time.Since(start).Seconds())
+}
+
 func (r *SystemReconciler) updateMetric(req ctrl.Request, systemID string, ready bool, controlPlane string) {
 	if r.Metrics == nil || r.Metrics.ControllerSystemStatusReady == nil {
 		return
@@ -193,12 +229,17 @@ func (r *SystemReconciler) updateMetric(req ctrl.Request, systemID string, ready
 }
 
 func (r *SystemReconciler) deleteMetrics(req ctrl.Request) {
-	if r.Metrics == nil || r.Metrics.ControllerSystemStatusReady == nil {
+	if r.Metrics == nil {
+		return
+	}
+	systemLabels := prometheus.Labels{"system_name": req.Name, "namespace": req.Namespace}
+	if r.Metrics.DriftDetected != nil {
+		r.Metrics.DriftDetected.DeletePartialMatch(systemLabels)
+	}
+	if r.Metrics.ControllerSystemStatusReady == nil {
 		return
 	}
-	if deleted := r.Metrics.ControllerSystemStatusReady.DeletePartialMatch(
-		prometheus.Labels{"system_name": req.Name, "namespace": req.Namespace},
-	); deleted > 1 {
+	if deleted := r.Metrics.ControllerSystemStatusReady.DeletePartialMatch(systemLabels); deleted > 1 {
 		log.Log.Error(errors.New("Failed to delete metric"), "Incorrect number of deleted metrics", "deleted", deleted)
 	}
 }
@@ -233,13 +274,7 @@ func (r *SystemReconciler) reconcileDeletion(
 		return ctrl.Result{}, nil
 	}
 
-	deletionProtected := false
-	if system.Spec.DeletionProtection != nil {
-		deletionProtected = *system.Spec.DeletionProtection
-	} else {
-		deletionProtected = r.Config.DeletionProtectionDefault
-	}
-	if !deletionProtected {
+	if !r.deletionProtected(system) {
 		log.Info("Deleting bundle and source for system in OCP")
 		uniqueName := system.OCPUniqueName(r.Config.SystemPrefix, r.Config.SystemSuffix)
 		if err := r.OCP.DeleteBundle(ctx, uniqueName); err != nil {
@@ -250,20 +285,17 @@ func (r *SystemReconciler) reconcileDeletion(
 			return ctrl.Result{}, ctrlerr.Wrap(err, "Could not delete source in OCP").
 				WithEvent(v1beta1.EventErrorDeleteSourceInOCP)
 		}
-
-		for _, datasource := range system.Spec.Datasources {
-			datasourceID := strings.ToLower(strings.ReplaceAll(datasource.Path, "/", "-"))
-			if err := r.OCP.DeleteSource(ctx, datasourceID); err != nil {
-				var httpErr *httperror.HTTPError
-				if errors.As(err, &httpErr) {
-					if httpErr.StatusCode == http.StatusInternalServerError {
-						continue
-					}
-				}
-				return ctrl.Result{}, ctrlerr.Wrap(err, "Could not delete datasource source in OCP").
-					WithEvent(v1beta1.EventErrorDeleteSourceInOCP)
+		for _, secretID := range r.gitCredentialsSecretIDs(system) {
+			if err := r.OCP.DeleteSecret(ctx, secretID); err != nil {
+				return ctrl.Result{}, ctrlerr.Wrap(err, "Could not delete git credentials secret in OCP").
+					WithEvent(v1beta1.EventErrorDeleteSecretInOCP)
 			}
 		}
+
+		if err := r.deleteDatasourceSources(ctx, log, system, systemDatasourceSourceIDs(system)); err != nil {
+			return ctrl.Result{}, ctrlerr.Wrap(err, "Could not delete datasource source in OCP").
+				WithEvent(v1beta1.EventErrorDeleteSourceInOCP)
+		}
 	}
 
 	log.Info("Removing finalizer")
@@ -275,6 +307,126 @@ func (r *SystemReconciler) reconcileDeletion(
 	return ctrl.Result{}, nil
 }
 
+// gitCredentialsSecretIDs returns the IDs of the git credentials secrets in
+// OCP which the controller created for the System: the one recorded in the
+// status and the one for the Secret currently referenced by the spec.
+func (r *SystemReconciler) gitCredentialsSecretIDs(system *v1beta1.System) []string {
+	ids := sets.New[string]()
+	if system.Status.GitCredentialsSecretID != "" {
+		ids.Insert(system.Status.GitCredentialsSecretID)
+	}
+	if id := r.desiredGitCredentialsSecretID(system); id != "" {
+		ids.Insert(id)
+	}
+	return sets.List(ids)
+}
+
+// desiredGitCredentialsSecretID returns the ID of the git credentials secret
+// in OCP for the Secret referenced by the System, or an empty string if the
+// System does not reference a Secret.
+func (r *SystemReconciler) desiredGitCredentialsSecretID(system *v1beta1.System) string {
+	if system.Spec.SourceControl == nil || system.Spec.SourceControl.Origin.CredentialsSecretName == "" {
+		return ""
+	}
+	return system.GitSecretID(r.Config.SystemPrefix, r.Config.SystemSuffix)
+}
+
+// reconcileRemovedGitCredentials deletes the git credentials secret recorded
+// in the status when the System no longer uses it, because the reference to
+// the Secret was removed or the unique name of the System changed, and
+// records the secret currently in use. It must be called after the source of
+// the System has been updated, so that the source no longer uses the secret.
+func (r *SystemReconciler) reconcileRemovedGitCredentials(
+	ctx context.Context,
+	log logr.Logger,
+	system *v1beta1.System,
+) error {
+	secretID := r.desiredGitCredentialsSecretID(system)
+	if removed := system.Status.GitCredentialsSecretID; removed != "" && removed != secretID {
+		if err := r.OCP.DeleteSecret(ctx, removed); err != nil {
+			return errors.Wrapf(err, "could not delete git credentials secret %s", removed)
+		}
+		log.Info("OCP git credentials deleted", "secret", removed)
+	}
+	system.Status.GitCredentialsSecretID = secretID
+	return nil
+}
+
+// reconcileRemovedDatasources deletes the sources of the datasources which
+// have been removed from the System since they were recorded in the status,
+// and records the current datasource sources. The sources are kept when the
+// System is deletion protected.
+func (r *SystemReconciler) reconcileRemovedDatasources(
+	ctx context.Context,
+	log logr.Logger,
+	system *v1beta1.System,
+	datasourceIDs []string,
+) error {
+	removed := sets.List(sets.New(system.Status.DatasourceSources...).Difference(sets.New(datasourceIDs...)))
+	if len(removed) > 0 && r.deletionProtected(system) {
+		// The sources stay in the status so they are deleted once the System
+		// is no longer deletion protected.
+		log.Info("Keeping sources of removed datasources as the system is deletion protected", "sources", removed)
+		system.Status.DatasourceSources = slices.Concat(datasourceIDs, removed)
+		return nil
+	}
+	if err := r.deleteDatasourceSources(ctx, log, system, removed); err != nil {
+		return err
+	}
+
+	system.Status.DatasourceSources = slices.Clone(datasourceIDs)
+	return nil
+}
+
+// deleteDatasourceSources deletes the given datasource sources of the System
+// in OCP. Sources which are still used by other Systems or Libraries are kept.
+func (r *SystemReconciler) deleteDatasourceSources(
+	ctx context.Context,
+	log logr.Logger,
+	system *v1beta1.System,
+	ids []string,
+) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	inUse, err := datasourceSourcesInUse(ctx, r, r.Config, system.UID)
+	if err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		if inUse.Has(id) {
+			log.Info("Keeping datasource source as it is used by other resources", "source", id)
+			continue
+		}
+		log.Info("Deleting datasource source", "source", id)
+		if err := r.OCP.DeleteSource(ctx, id); err != nil {
+			return errors.Wrapf(err, "could not delete source %s", id)
+		}
+		if err := r.OCP.DeleteSecret(ctx, datasourceSecretID(id)); err != nil {
+			return errors.Wrapf(err, "could not delete credentials of source %s", id)
+		}
+	}
+	return nil
+}
+
+// systemDatasourceSourceIDs returns the IDs of the datasource sources of the
+// System, both from the spec and those recorded in the status.
+func systemDatasourceSourceIDs(system *v1beta1.System) []string {
+	ids := sets.New(system.Status.DatasourceSources...)
+	for _, datasource := range system.Spec.Datasources {
+		ids.Insert(datasourceSourceID(datasource.Path))
+	}
+	return sets.List(ids)
+}
+
+// deletionProtected returns whether the OCP resources of the System should be
+// kept when the System is deleted.
+func (r *SystemReconciler) deletionProtected(system *v1beta1.System) bool {
+	return deletionProtected(system.Spec.DeletionProtection, r.Config.DeletionProtectionDefault)
+}
+
 func (r *SystemReconciler) reconcile(
 	ctx context.Context,
 	log logr.Logger,
@@ -296,22 +448,30 @@ func (r *SystemReconciler) ocpReconcile(
 	ctx context.Context,
 	log logr.Logger,
 	system *v1beta1.System) (ctrl.Result, error) {
-	var requirements []ocp.Requirement
+	var (
+		requirements  []ocp.Requirement
+		datasourceIDs []string
+		// driftCorrected records whether a resource in OCP was created or
+		// updated, so a reconcile which changed nothing is not reported.
+		driftCorrected bool
+		previous       = system.Status.DeepCopy()
+	)
 
 	for _, datasource := range system.Spec.Datasources {
-		datasource.Path = strings.ToLower(strings.ReplaceAll(datasource.Path, "/", "-"))
+		datasourceID := datasourceSourceID(datasource.Path)
+		datasourceIDs = append(datasourceIDs, datasourceID)
 
-		created, err := r.createSourceIfNotExists(ctx, log, datasource)
+		changed, err := r.reconcileDatasourceSource(ctx, log, system, datasource)
 		if err != nil {
 			return ctrl.Result{}, ctrlerr.Wrap(err,
-				fmt.Sprintf("ocpReconcile: Could not ensure datasource/source exists: %s", datasource.Path),
+				fmt.Sprintf("ocpReconcile: Could not reconcile datasource/source: %s", datasourceID),
 			).WithEvent(v1beta1.EventErrorUpdateSource).
 				WithSystemCondition(v1beta1.ConditionTypeRequirementsUpdated)
 		}
 
-		if created && r.WebhookClient != nil {
+		if changed && r.WebhookClient != nil {
 			log.Info("Calling datasource changed webhook")
-			if err := r.WebhookClient.SystemDatasourceChangedOCP(ctx, log, datasource.Path); err != nil {
+			if err := r.WebhookClient.SystemDatasourceChangedOCP(ctx, log, datasourceID); err != nil {
 				err = ctrlerr.Wrap(err, "Could not call datasource changed webhook").
 					WithEvent(v1beta1.EventErrorCallWebhook).
 					WithSystemCondition(v1beta1.ConditionTypeRequirementsUpdated)
@@ -320,40 +480,79 @@ func (r *SystemReconciler) ocpReconcile(
 			}
 		}
 
-		requirements = append(requirements, ocp.NewRequirement(datasource.Path))
+		driftCorrected = driftCorrected || changed
+		requirements = append(requirements, ocp.NewRequirement(datasourceID))
 	}
 	system.SetCondition(v1beta1.ConditionTypeRequirementsUpdated, metav1.ConditionTrue)
 
 	reconcileSystemSourceStart := time.Now()
 	uniqueName := system.OCPUniqueName(r.Config.SystemPrefix, r.Config.SystemSuffix)
-	result, err := r.reconcileSystemSource(ctx, log, system, uniqueName)
-	r.Metrics.ReconcileSegmentTime.
-		WithLabelValues("reconcileSystemSourceOcp").
-		Observe(time.Since(reconcileSystemSourceStart).Seconds())
+	system.Status.UniqueName = uniqueName
+	result, source, sourceApplied, err := r.reconcileSystemSource(ctx, log, system, uniqueName)
+	r.observeSegmentTime("reconcileSystemSourceOcp", reconcileSystemSourceStart)
 	if err != nil {
 		return result, ctrlerr.Wrap(
 			err, fmt.Sprintf("ocpReconcile: Could not reconcile system source: %s", uniqueName)).
 			WithEvent(v1beta1.EventErrorUpdateSource).
 			WithSystemCondition(v1beta1.ConditionTypeSystemSourceUpdated)
 	}
+	driftCorrected = driftCorrected || sourceApplied
 	requirements = append(requirements, ocp.NewRequirement(uniqueName))
 	system.SetCondition(v1beta1.ConditionTypeSystemSourceUpdated, metav1.ConditionTrue)
 
-	defaultRequirements := ocp.ToRequirements(r.Config.OPAControlPlaneConfig.DefaultRequirements)
+	if err := r.reconcileRemovedGitCredentials(ctx, log, system); err != nil {
+		return ctrl.Result{}, ctrlerr.Wrap(err, "ocpReconcile: Could not delete removed git credentials").
+			WithEvent(v1beta1.EventErrorDeleteSecretInOCP).
+			WithSystemCondition(v1beta1.ConditionTypeSystemSourceUpdated)
+	}
+
+	// Sources without git are revisioned by their files.
+	var filesHash string
+	if source.Git == nil {
+		filesHash = filesRevision(source.EmbeddedFiles)
+	}
+
+	librarySources, err := r.selectedLibrarySources(ctx, system)
+	if err != nil {
+		return ctrl.Result{}, ctrlerr.Wrap(err, "ocpReconcile: Could not select libraries").
+			WithEvent(v1beta1.EventErrorUpdateBundle).
+			WithSystemCondition(v1beta1.ConditionTypeRequirementsUpdated)
+	}
+
+	// The requirements of the System, the selected Libraries and the default
+	// requirements are libraries, which are revisioned by their git commit.
+	libraryRequirements := systemRequirements(system, librarySources, r.Config.OPAControlPlaneConfig.DefaultRequirements)
+
+	bundleStorage, err := resolveSystemBundleStorage(r.Config, system, uniqueName)
+	if err != nil {
+		return ctrl.Result{}, ctrlerr.Wrap(err, "ocpReconcile: Invalid bundle storage").
+			WithEvent(v1beta1.EventErrorUpdateBundle).
+			WithSystemCondition(v1beta1.ConditionTypeSystemBundleUpdated)
+	}
+	system.Status.BundleObjectKey = bundleStorage.key
 
 	reconcileSystemBundleStart := time.Now()
-	result, err = r.reconcileSystemBundle(ctx, uniqueName, requirements, defaultRequirements)
-	r.Metrics.ReconcileSegmentTime.
-		WithLabelValues("reconcileSystemBundleOcp").
-		Observe(time.Since(reconcileSystemBundleStart).Seconds())
+	result, bundleApplied, err := r.reconcileSystemBundle(
+		ctx, log, system, uniqueName, bundleStorage, requirements, libraryRequirements, filesHash)
+	r.observeSegmentTime("reconcileSystemBundleOcp", reconcileSystemBundleStart)
 	if err != nil {
 		return result, ctrlerr.Wrap(err, fmt.Sprintf("ocpReconcile: Could not reconcile system bundle: %s", uniqueName)).
 			WithEvent(v1beta1.EventErrorUpdateBundle).
 			WithSystemCondition(v1beta1.ConditionTypeSystemBundleUpdated)
 	}
+	driftCorrected = driftCorrected || bundleApplied
 	system.SetCondition(v1beta1.ConditionTypeSystemBundleUpdated, metav1.ConditionTrue)
 
+	// Sources of removed datasources are deleted after the bundle has been
+	// updated, so that the bundle no longer requires them.
+	if err := r.reconcileRemovedDatasources(ctx, log, system, datasourceIDs); err != nil {
+		return ctrl.Result{}, ctrlerr.Wrap(err, "ocpReconcile: Could not delete sources of removed datasources").
+			WithEvent(v1beta1.EventErrorDeleteSourceInOCP).
+			WithSystemCondition(v1beta1.ConditionTypeRequirementsUpdated)
+	}
+
 	secretName := fmt.Sprintf("%s-opa-secret", system.Name)
+	system.Status.OPASecretName = secretName
 	result, secretUpdated, err := r.reconcileOPASecret(ctx, log, system, uniqueName, secretName)
 	if err != nil {
 		return result, ctrlerr.Wrap(err, fmt.Sprintf("ocpReconcile: Could not reconcile OPA Secret: %s", secretName)).
@@ -373,7 +572,9 @@ func (r *SystemReconciler) ocpReconcile(
 	system.SetCondition(v1beta1.ConditionTypeOPASecretUpdated, metav1.ConditionTrue)
 
 	configmapName := fmt.Sprintf("%s-opa-config", system.Name)
-	result, updatedOPAConfigMap, err := r.reconcileOPAConfigMapForOCP(ctx, log, system, uniqueName, configmapName)
+	system.Status.OPAConfigMapName = configmapName
+	result, updatedOPAConfigMap, err := r.reconcileOPAConfigMapForOCP(
+		ctx, log, system, uniqueName, bundleStorage, configmapName)
 	if err != nil {
 		return result, ctrlerr.Wrap(err, fmt.Sprintf("ocpReconcile: Could not reconcile OPA ConfigMap: %s", configmapName)).
 			WithEvent(v1beta1.EventErrorUpdateOPAConfigMap).
@@ -391,18 +592,33 @@ func (r *SystemReconciler) ocpReconcile(
 	}
 	system.SetCondition(v1beta1.ConditionTypeOPAConfigMapUpdated, metav1.ConditionTrue)
 
+	lastAppliedSpecHash, err := specHash(&system.Spec)
+	if err != nil {
+		return ctrl.Result{}, ctrlerr.Wrap(err, "ocpReconcile: Could not hash system spec").
+			WithEvent(v1beta1.EventErrorUpdateStatus)
+	}
+
 	system.Status.Ready = true
 	system.Status.Phase = v1beta1.SystemPhaseCreated
 	system.Status.FailureMessage = ""
+	system.Status.ObservedGeneration = system.Generation
+	system.Status.LastAppliedSpecHash = lastAppliedSpecHash
 
 	if system.GetCondition(v1beta1.ConditionTypeOPAUpToDate) == nil ||
 		*system.GetCondition(v1beta1.ConditionTypeOPAUpToDate) != metav1.ConditionTrue {
 		system.SetCondition(v1beta1.ConditionTypeOPAUpToDate, metav1.ConditionTrue)
 	}
 
+	// Periodic resyncs which found OCP up to date neither write the status
+	// nor emit an event, as nothing happened to the System.
+	if !driftCorrected && !statusChanged(previous, &system.Status) {
+		log.Info("OPA Control Plane system unchanged")
+		return ctrl.Result{RequeueAfter: r.resyncInterval()}, nil
+	}
+
 	updateStatusStart := time.Now()
 	err = r.Status().Update(ctx, system)
-	r.Metrics.ReconcileSegmentTime.WithLabelValues("updateStatusOcp").Observe(time.Since(updateStatusStart).Seconds())
+	r.observeSegmentTime("updateStatusOcp", updateStatusStart)
 	if err != nil {
 		return ctrl.Result{}, ctrlerr.Wrap(err, "Could not change status.phase to Created").
 			WithEvent(v1beta1.EventErrorPhaseToCreated)
@@ -411,7 +627,7 @@ func (r *SystemReconciler) ocpReconcile(
 	msg := "OPA Control Plane reconciliation completed"
 	r.Recorder.Eventf(system, nil, corev1.EventTypeNormal, "ReconciliationCompleted", "Reconcile", msg)
 	log.Info(msg)
-	return ctrl.Result{}, nil
+	return ctrl.Result{RequeueAfter: r.resyncInterval()}, nil
 }
 
 func (r *SystemReconciler) reconcileOPAConfigMapForOCP(
@@ -419,6 +635,7 @@ func (r *SystemReconciler) reconcileOPAConfigMapForOCP(
 	log logr.Logger,
 	system *v1beta1.System,
 	uniqueName string,
+	bundleStorage systemBundleStorage,
 	configmapName string,
 ) (ctrl.Result, bool, error) {
 	log.Info("Reconciling OPA ConfigMap")
@@ -432,32 +649,26 @@ func (r *SystemReconciler) reconcileOPAConfigMapForOCP(
 		}
 	}
 
-	bundleURL, err := url.JoinPath(r.Config.OPA.BundleServer.URL, r.Config.OPA.BundleServer.Path)
+	bundleURL, err := opaBundleServerURL(*r.Config.OPA.BundleServer)
 	if err != nil {
 		return ctrl.Result{}, false, ctrlerr.Wrap(err, "Invalid OPA BundleServer URL or path").
 			WithEvent(v1beta1.EventErrorConvertOPAConf).
 			WithSystemCondition(v1beta1.ConditionTypeOPAConfigMapUpdated)
 	}
 
-	bundleServiceCredentials := &ocp.ServiceCredentials{
-		S3: &ocp.S3Signing{
-			S3EnvironmentCredentials: map[string]ocp.EmptyStruct{},
-		},
-	}
-	if r.Config.OPA.BundleServer.TokenPath != "" {
-		bundleServiceCredentials = &ocp.ServiceCredentials{
-			Bearer: &ocp.Bearer{
-				TokenPath: r.Config.OPA.BundleServer.TokenPath,
-			},
-		}
+	if bundleStorage.bundleServerURL != "" {
+		bundleURL = bundleStorage.bundleServerURL
 	}
 
+	bundleService, bundleResource := opaBundleService(
+		bundleStorage.objectStorage,
+		*r.Config.OPA.BundleServer,
+		bundleURL,
+		bundleStorage.key,
+	)
+
 	opaconf := ocp.OPAConfig{
-		BundleService: &ocp.OPAServiceConfig{
-			Name:        r.Config.OPA.BundleServer.Name,
-			URL:         bundleURL,
-			Credentials: bundleServiceCredentials,
-		},
+		BundleService: bundleService,
 		LogService: &ocp.OPAServiceConfig{
 			Name: r.Config.OPA.DecisionAPIConfig.Name,
 			URL:  r.Config.OPA.DecisionAPIConfig.ServiceURL,
@@ -468,9 +679,14 @@ func (r *SystemReconciler) reconcileOPAConfigMapForOCP(
 			},
 		},
 		DecisionLogReporting: r.Config.OPA.DecisionAPIConfig.Reporting,
-		BundleResource:       fmt.Sprintf("bundles/%s/bundle.tar.gz", uniqueName),
+		BundleResource:       bundleResource,
 		UniqueName:           uniqueName,
 		Namespace:            system.Namespace,
+		DiscoveryOverrides:   system.Spec.DiscoveryOverrides,
+		EnableDeltaBundles:   deltaBundlesEnabled(system),
+	}
+	if len(system.Spec.DecisionMappings) > 0 {
+		opaconf.DecisionLogMaskDecision = decisionlog.MaskDecision
 	}
 
 	expectedOPAConfigMap, err = k8sconv.OPAConfToK8sOPAConfigMapforOCP(opaconf, r.Config.OPA, customConfig, log)
@@ -549,9 +765,7 @@ func (r *SystemReconciler) reconcileOPASecret(
 
 	reconcilek8sOPASecret := time.Now()
 	result, secretUpdated, err := r.reconcilek8sOPASecret(ctx, log, system, secretName)
-	r.Metrics.ReconcileSegmentTime.
-		WithLabelValues("reconcilek8sOPASecretOcp").
-		Observe(time.Since(reconcilek8sOPASecret).Seconds())
+	r.observeSegmentTime("reconcilek8sOPASecretOcp", reconcilek8sOPASecret)
 	if err != nil {
 		return result, false, err
 	}
@@ -633,43 +847,138 @@ func (r *SystemReconciler) reconcilek8sOPASecret(
 
 func (r *SystemReconciler) reconcileSystemBundle(
 	ctx context.Context,
+	log logr.Logger,
+	system *v1beta1.System,
 	uniqueName string,
+	bundleStorage systemBundleStorage,
 	requirements []ocp.Requirement,
-	defaultRequirements []ocp.Requirement) (ctrl.Result, error) {
-	if r.Config.OPAControlPlaneConfig.BundleObjectStorage.S3 == nil {
-		return ctrl.Result{}, ctrlerr.New("reconcileSystemBundle: no object storage configured")
-	}
-
-	objectStorage := ocp.ObjectStorage{
-		AmazonS3: &ocp.AmazonS3{
-			Bucket:      r.Config.OPAControlPlaneConfig.BundleObjectStorage.S3.Bucket,
-			Key:         fmt.Sprintf("bundles/%s/bundle.tar.gz", uniqueName),
-			Region:      r.Config.OPAControlPlaneConfig.BundleObjectStorage.S3.Region,
-			URL:         r.Config.OPAControlPlaneConfig.BundleObjectStorage.S3.URL,
-			Credentials: r.Config.OPAControlPlaneConfig.BundleObjectStorage.S3.OCPConfigSecretName,
-		},
+	defaultRequirements []ocp.Requirement,
+	filesHash string) (ctrl.Result, bool, error) {
+	objectStorage, err := bundleObjectStorage(bundleStorage.objectStorage, bundleStorage.key)
+	if err != nil {
+		return ctrl.Result{}, false, ctrlerr.Wrap(err, "reconcileSystemBundle: invalid object storage configuration")
+	}
+
+	bundleLabels := labels.OCPBundleLabels(r.Config.ControllerClass, system.Namespace, system.Name)
+	if r.deletionProtected(system) {
+		// Keeps the garbage collector from removing the bundle after the System
+		// is deleted.
+		bundleLabels[labels.LabelDeletionProtection] = labels.LabelValueDeletionProtected
 	}
 
 	bundle := &ocp.PutBundleRequest{
 		Name:          uniqueName,
+		Labels:        bundleLabels,
 		ObjectStorage: objectStorage,
 		Requirements:  append(requirements, defaultRequirements...),
-		Revision:      bundleRevision(uniqueName, defaultRequirements, requirements),
+		Revision:      bundleRevision(uniqueName, defaultRequirements, requirements, filesHash),
+		ExcludedFiles: bundleExcludedFiles(r.Config, system),
+		DeltaBundles:  r.ocpDeltaBundles(system),
 	}
-	err := r.OCP.PutBundle(ctx, bundle)
 
+	apply, err := r.detectDrift(log, system, "bundle", uniqueName, func() (bool, error) {
+		return bundleUpToDate(ctx, r.OCP, bundle)
+	})
 	if err != nil {
-		return ctrl.Result{}, ctrlerr.Wrap(err, "ocpReconcile: could not create or update bundle in OCP")
+		return ctrl.Result{}, false, ctrlerr.Wrap(err, "reconcileSystemBundle: could not compare bundle with OCP")
 	}
-	return ctrl.Result{}, nil
+	if !apply {
+		log.Info("OCP bundle up to date", "bundle", uniqueName)
+		return ctrl.Result{}, false, nil
+	}
+
+	if err := r.OCP.PutBundle(ctx, bundle); err != nil {
+		return ctrl.Result{}, false, ctrlerr.Wrap(err, "ocpReconcile: could not create or update bundle in OCP")
+	}
+	return ctrl.Result{}, true, nil
+}
+
+// resyncInterval returns how often Systems are reconciled to detect drift in
+// OCP. Drift detection is disabled when it is zero.
+func (r *SystemReconciler) resyncInterval() time.Duration {
+	if r.Config.OPAControlPlaneConfig == nil {
+		return 0
+	}
+	return r.Config.OPAControlPlaneConfig.ResyncInterval.Duration
+}
+
+// detectDrift reports whether a source or bundle must be applied to OCP. When
+// drift detection is disabled it is always applied. Otherwise it is applied
+// when upToDate reports that OCP does not match the desired state, and drift
+// is recorded if the current generation of the System had already been
+// applied.
+func (r *SystemReconciler) detectDrift(
+	log logr.Logger,
+	system *v1beta1.System,
+	resource string,
+	id string,
+	upToDate func() (bool, error),
+) (bool, error) {
+	if r.resyncInterval() <= 0 {
+		return true, nil
+	}
+
+	ok, err := upToDate()
+	if err != nil {
+		return false, err
+	}
+	if ok {
+		return false, nil
+	}
+
+	if system.Status.ObservedGeneration == system.Generation {
+		msg := fmt.Sprintf("The %s %s in OCP has drifted from the System and is reapplied", resource, id)
+		log.Info(msg)
+		if r.Recorder != nil {
+			r.Recorder.Eventf(system, nil, corev1.EventTypeWarning, string(v1beta1.EventDriftDetected), "Reconcile", msg)
+		}
+		if r.Metrics != nil && r.Metrics.DriftDetected != nil {
+			r.Metrics.DriftDetected.WithLabelValues(system.Name, system.Namespace, resource).Inc()
+		}
+	}
+	return true, nil
+}
+
+// statusChanged reports whether the status of a System differs from the
+// previous status, ignoring when the conditions were last probed.
+func statusChanged(previous, current *v1beta1.SystemStatus) bool {
+	previous, current = previous.DeepCopy(), current.DeepCopy()
+	for _, status := range []*v1beta1.SystemStatus{previous, current} {
+		for i := range status.Conditions {
+			status.Conditions[i].LastProbeTime = metav1.Time{}
+		}
+	}
+	return !equality.Semantic.DeepEqual(previous, current)
+}
+
+// deltaBundlesEnabled returns whether delta bundles are enabled for the
+// System.
+func deltaBundlesEnabled(system *v1beta1.System) bool {
+	return system.Spec.EnableDeltaBundles != nil && *system.Spec.EnableDeltaBundles
+}
+
+// ocpDeltaBundles returns whether delta bundles are requested from OCP for the
+// System. They are only requested when enabled in the controller config, as
+// not every OCP version supports them.
+func (r *SystemReconciler) ocpDeltaBundles(system *v1beta1.System) bool {
+	if r.Config.OPAControlPlaneConfig == nil || !r.Config.OPAControlPlaneConfig.DeltaBundles {
+		return false
+	}
+	return deltaBundlesEnabled(system)
 }
 
 // bundleRevision produces a Rego template string containing:
 // - data: sha256 hash of all SQL hashes (datasources + libraries)
-// - git-sha: the git commit for the system's unique source
-// - libraries: sha256 hash of all library (default requirement) git commits
+// - git-sha: the git commit for the system's unique source, or files: the
+// given hash of the files of a system source without git
+// - libraries: sha256 hash of all library (system and default requirement) git commits
 // for example "data:sha256,git-sha:commitsha,libraries:sha256"
-func bundleRevision(uniqueName string, defaultRequirements []ocp.Requirement, requirements []ocp.Requirement) string {
+func bundleRevision(
+	uniqueName string,
+	defaultRequirements []ocp.Requirement,
+	requirements []ocp.Requirement,
+	filesHash string,
+) string {
 	// SQL hashes for datasources
 	datasourceSQLHashes := make([]string, len(requirements))
 	for i, req := range requirements {
@@ -693,11 +1002,17 @@ func bundleRevision(uniqueName string, defaultRequirements []ocp.Requirement, re
 	}
 	libraryHashSet := strings.Join(libraryGitCommits, ", ")
 
+	// git sha for the system source
+	sourceRevision := fmt.Sprintf(`git-sha:{input.sources["%s"].git.commit}`, uniqueName)
+	if filesHash != "" {
+		sourceRevision = "files:" + filesHash
+	}
+
 	return fmt.Sprintf(
 		`$"data:{crypto.sha256(concat("", [%s]))},`+
-			`git-sha:{input.sources["%s"].git.commit},`+ // git sha for the system source
+			`%s,`+
 			`libraries:{crypto.sha256(concat("", [%s]))}"`,
-		allSQLHashSet, uniqueName, libraryHashSet,
+		allSQLHashSet, sourceRevision, libraryHashSet,
 	)
 }
 
@@ -705,21 +1020,94 @@ func (r *SystemReconciler) reconcileSystemSource(
 	ctx context.Context,
 	log logr.Logger,
 	system *v1beta1.System,
-	uniqueName string) (ctrl.Result, error) {
+	uniqueName string) (ctrl.Result, *ocp.PutSourceRequest, bool, error) {
+
+	if system.Spec.SourceControl == nil && len(system.Spec.Files) == 0 && len(system.Spec.FilesFrom) == 0 {
+		return ctrl.Result{}, nil, false, ctrlerr.New(
+			"reconcileSystemSource: no source control or files configured on system")
+	}
+
+	source := &ocp.PutSourceRequest{
+		Name: uniqueName,
+	}
+
+	if system.Spec.SourceControl != nil {
+		gitConfig, err := r.systemGitConfig(ctx, log, system)
+		if err != nil {
+			return ctrl.Result{}, nil, false, err
+		}
+		source.Git = gitConfig
+	}
+
+	embeddedFiles, err := r.systemEmbeddedFiles(ctx, system)
+	if err != nil {
+		return ctrl.Result{}, nil, false, err
+	}
+	source.EmbeddedFiles = embeddedFiles
+
+	apply, err := r.detectDrift(log, system, "source", uniqueName, func() (bool, error) {
+		return sourceUpToDate(ctx, r.OCP, uniqueName, source)
+	})
+	if err != nil {
+		return ctrl.Result{}, nil, false, ctrlerr.Wrap(err, "reconcileSystemSource: could not compare source with OCP")
+	}
+	if !apply {
+		log.Info("OCP source up to date", "source", uniqueName)
+		return ctrl.Result{}, source, false, nil
+	}
+
+	_, err = r.OCP.PutSource(ctx, uniqueName, source)
+	if err != nil {
+		return ctrl.Result{}, nil, false, ctrlerr.Wrap(err,
+			"reconcileSystemSource: could not create or update source in OCP")
+	}
+	log.Info("OCP source upserted", "source", uniqueName)
+	return ctrl.Result{}, source, true, nil
+}
 
-	if system.Spec.SourceControl == nil {
-		return ctrl.Result{}, ctrlerr.New("reconcileSystemSource: no source control configured on system")
+// selectedLibrarySources returns the sorted source names of the Libraries
+// selected by the library selector of the System. Libraries which are being
+// deleted are not selected, so that the bundle stops requiring their sources.
+func (r *SystemReconciler) selectedLibrarySources(ctx context.Context, system *v1beta1.System) ([]string, error) {
+	if system.Spec.LibrarySelector == nil {
+		return nil, nil
+	}
+	selector, err := metav1.LabelSelectorAsSelector(system.Spec.LibrarySelector)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid library selector")
+	}
+
+	var libraries styrav1alpha1.LibraryList
+	if err := r.List(ctx, &libraries, &client.ListOptions{LabelSelector: selector}); err != nil {
+		return nil, errors.Wrap(err, "could not list Libraries")
 	}
 
+	sources := sets.New[string]()
+	for i := range libraries.Items {
+		library := &libraries.Items[i]
+		if !library.DeletionTimestamp.IsZero() || !labels.ControllerClassMatches(library, r.Config.ControllerClass) {
+			continue
+		}
+		sources.Insert(library.Spec.Name)
+	}
+	return sets.List(sources), nil
+}
+
+// systemGitConfig returns the git configuration of the source of the System.
+func (r *SystemReconciler) systemGitConfig(
+	ctx context.Context,
+	log logr.Logger,
+	system *v1beta1.System,
+) (*ocp.GitConfig, error) {
 	if !isURLValid(system.Spec.SourceControl.Origin.URL) {
-		return ctrl.Result{}, ctrlerr.New("Invalid URL for source control")
+		return nil, ctrlerr.New("Invalid URL for source control")
 	}
 
 	gitConfig := &ocp.GitConfig{
-		Repo:          system.Spec.SourceControl.Origin.URL,
-		IncludedFiles: []string{"*.rego"},
-		ExcludedFiles: []string{"*_test.rego"},
+		Repo: system.Spec.SourceControl.Origin.URL,
 	}
+	gitConfig.IncludedFiles, gitConfig.ExcludedFiles = gitFilePatterns(r.Config,
+		system.Spec.SourceControl.Origin.IncludedFiles, system.Spec.SourceControl.Origin.ExcludedFiles)
 	if system.Spec.SourceControl.Origin.Commit != "" {
 		gitConfig.Commit = system.Spec.SourceControl.Origin.Commit
 	} else if system.Spec.SourceControl.Origin.Reference != "" {
@@ -728,90 +1116,263 @@ func (r *SystemReconciler) reconcileSystemSource(
 	if system.Spec.SourceControl.Origin.Path != "" {
 		gitConfig.Path = system.Spec.SourceControl.Origin.Path
 	}
-	gitCredentialFound := false
-	for _, cred := range r.Config.OPAControlPlaneConfig.GitCredentials {
-		if strings.Contains(system.Spec.SourceControl.Origin.URL, cred.RepoPrefix) {
-			gitConfig.CredentialID = cred.ID
-			gitCredentialFound = true
-			break
+	if system.Spec.SourceControl.Origin.CredentialsSecretName != "" {
+		credentialID, err := r.reconcileGitCredentials(ctx, log, system)
+		if err != nil {
+			system.SetCondition(v1beta1.ConditionTypeCredentialsResolved, metav1.ConditionFalse)
+			return nil, err
+		}
+		gitConfig.CredentialID = credentialID
+	} else {
+		cred, err := gitcredentials.Resolve(r.Config.OPAControlPlaneConfig.GitCredentials,
+			system.Spec.SourceControl.Origin.URL, system.Namespace)
+		if err != nil {
+			system.SetCondition(v1beta1.ConditionTypeCredentialsResolved, metav1.ConditionFalse)
+			return nil, ctrlerr.Wrap(err, "reconcileSystemSource: Unsupported git repository")
 		}
+		gitConfig.CredentialID = cred.ID
 	}
-	if !gitCredentialFound {
-		return ctrl.Result{}, fmt.Errorf(
-			"reconcileSystemSource: Unsupported git repository: %s",
-			system.Spec.SourceControl.Origin.URL)
+	system.SetCondition(v1beta1.ConditionTypeCredentialsResolved, metav1.ConditionTrue)
+	return gitConfig, nil
+}
+
+// systemEmbeddedFiles returns the files embedded in the source of the System:
+// the inline files, the keys of the referenced ConfigMaps and the generated
+// decision log mask policy. A path may only be defined once.
+func (r *SystemReconciler) systemEmbeddedFiles(
+	ctx context.Context,
+	system *v1beta1.System,
+) (map[string]string, error) {
+	files := map[string]string{}
+	add := func(dir, name, content string) error {
+		filePath, err := embeddedFilePath(dir, name)
+		if err != nil {
+			return err
+		}
+		if _, ok := files[filePath]; ok {
+			return errors.Errorf("file %s is defined more than once", filePath)
+		}
+		files[filePath] = content
+		return nil
 	}
 
-	_, err := r.OCP.PutSource(ctx, uniqueName, &ocp.PutSourceRequest{
-		Name: uniqueName,
-		Git:  gitConfig,
-	})
+	for name, content := range system.Spec.Files {
+		if err := add("", name, content); err != nil {
+			return nil, ctrlerr.Wrap(err, "reconcileSystemSource: invalid file")
+		}
+	}
+
+	for _, filesFrom := range system.Spec.FilesFrom {
+		var cm corev1.ConfigMap
+		key := types.NamespacedName{Name: filesFrom.ConfigMapName, Namespace: system.Namespace}
+		if err := r.Get(ctx, key, &cm); err != nil {
+			return nil, ctrlerr.Wrap(err, fmt.Sprintf("Could not fetch files ConfigMap: %s", filesFrom.ConfigMapName))
+		}
+		for name, content := range cm.Data {
+			if err := add(filesFrom.Path, name, content); err != nil {
+				return nil, ctrlerr.Wrap(err, fmt.Sprintf("Invalid file in ConfigMap: %s", filesFrom.ConfigMapName))
+			}
+		}
+	}
+
+	maskFiles, err := decisionlog.EmbeddedFiles(system.Spec.DecisionMappings)
 	if err != nil {
-		return ctrl.Result{}, ctrlerr.Wrap(err, "reconcileSystemSource: could not create or update source in OCP")
+		return nil, ctrlerr.Wrap(err, "reconcileSystemSource: could not generate decision log mask policy")
 	}
-	log.Info("OCP source upserted", "source", uniqueName)
-	return ctrl.Result{}, nil
+	for name, content := range maskFiles {
+		if err := add("", name, content); err != nil {
+			return nil, ctrlerr.Wrap(err, "reconcileSystemSource: invalid file")
+		}
+	}
+
+	if len(files) == 0 {
+		return nil, nil
+	}
+	return files, nil
 }
 
-func isURLValid(rawURL string) bool {
-	if rawURL == "" {
-		return true
+// reconcileDatasourceSource ensures the source backing a datasource exists in
+// OCP. Datasources with a type are reconciled into the source together with
+// their credentials, so changes to the spec are written to OCP. A typed
+// datasource owned by another System is rejected instead of overwriting its
+// source. The returned bool reports whether the source was created or updated.
+func (r *SystemReconciler) reconcileDatasourceSource(
+	ctx context.Context,
+	log logr.Logger,
+	system *v1beta1.System,
+	datasource v1beta1.Datasource,
+) (bool, error) {
+	id := datasourceSourceID(datasource.Path)
+	if datasource.Type == "" {
+		return createSourceIfNotExists(ctx, log, r.OCP, id)
 	}
 
-	parsedURL, err := url.Parse(rawURL)
+	owner, err := typedDatasourceOwner(ctx, r, r.Config, id)
 	if err != nil {
-		return false
+		return false, ctrlerr.Wrap(err, "Could not determine the owner of the datasource")
+	}
+	if owner != nil && owner.UID != system.UID {
+		return false, ctrlerr.New(fmt.Sprintf("Datasource %s is already defined by System %s/%s",
+			datasource.Path, owner.Namespace, owner.Name))
 	}
 
-	// URL must have a scheme (http or https) and a host
-	if parsedURL.Scheme == "" || parsedURL.Host == "" {
-		return false
+	var secretID string
+	if datasource.CredentialsSecretName != "" {
+		secretID = datasourceSecretID(id)
+		if err := r.reconcileDatasourceCredentials(ctx, log, system, datasource, secretID); err != nil {
+			return false, err
+		}
 	}
 
-	// Reject URLs with brackets in the path (invalid for repository URLs)
-	if strings.Contains(parsedURL.Path, "[") || strings.Contains(parsedURL.Path, "]") {
-		return false
+	source, err := datasourceSource(id, datasource, secretID)
+	if err != nil {
+		return false, ctrlerr.Wrap(err, fmt.Sprintf("Invalid datasource: %s", datasource.Path))
 	}
 
-	return true
+	apply, err := r.detectDrift(log, system, "datasource", id, func() (bool, error) {
+		return sourceUpToDate(ctx, r.OCP, id, source)
+	})
+	if err != nil {
+		return false, ctrlerr.Wrap(err, "Could not compare datasource source with OCP")
+	}
+	if !apply {
+		log.Info("OCP datasource source up to date", "source", id)
+		return false, nil
+	}
+
+	if _, err := r.OCP.PutSource(ctx, id, source); err != nil {
+		return false, ctrlerr.Wrap(err, "Could not create or update datasource source in OCP")
+	}
+	log.Info("OCP datasource source upserted", "source", id)
+	return true, nil
 }
 
-func (r *SystemReconciler) createSourceIfNotExists(
+// reconcileDatasourceCredentials pushes the credentials in the Secret
+// referenced by the datasource to OCP as the secret with the given ID.
+func (r *SystemReconciler) reconcileDatasourceCredentials(
 	ctx context.Context,
 	log logr.Logger,
-	source v1beta1.Datasource) (bool, error) {
-	_, err := r.OCP.GetSource(ctx, source.Path)
-	if err == nil {
-		log.Info("Source already exists", "source", source.Path)
-		return false, nil
+	system *v1beta1.System,
+	datasource v1beta1.Datasource,
+	secretID string,
+) error {
+	secretName := datasource.CredentialsSecretName
+
+	var k8sSecret corev1.Secret
+	if err := r.Get(ctx, types.NamespacedName{Name: secretName, Namespace: system.Namespace}, &k8sSecret); err != nil {
+		return ctrlerr.Wrap(err, fmt.Sprintf("Could not fetch datasource credentials secret: %s", secretName))
+	}
+	if len(k8sSecret.Data) == 0 {
+		return ctrlerr.New(fmt.Sprintf("Datasource credentials secret is empty: %s", secretName))
 	}
 
-	var httpErr *httperror.HTTPError
-	if errors.As(err, &httpErr) {
-		if httpErr.StatusCode != http.StatusNotFound {
-			return false, ctrlerr.Wrap(err, "GetSource in createSourceIfNotExists failed")
-		}
-	} else {
-		return false, ctrlerr.Wrap(err, "GetSource in createSourceIfNotExists failed")
+	value := make(map[string]interface{}, len(k8sSecret.Data))
+	for key, data := range k8sSecret.Data {
+		value[key] = string(data)
+	}
+	if err := r.OCP.PutSecret(ctx, secretID, &ocp.Secret{Name: secretID, Value: value}); err != nil {
+		return ctrlerr.Wrap(err, fmt.Sprintf("Could not update datasource credentials in OCP: %s", secretID))
 	}
+	log.Info("OCP datasource credentials upserted", "secret", secretID)
+	return nil
+}
 
-	log.Info("Creating source", "source", source.Path)
-	_, err = r.OCP.PutSource(ctx, source.Path, &ocp.PutSourceRequest{
-		Name: source.Path,
-	})
+// reconcileGitCredentials pushes the git credentials in the Secret referenced
+// by the System to OCP and returns the ID of the secret in OCP. The secret is
+// written on every reconcile, so changes to the Secret are rotated into OCP.
+func (r *SystemReconciler) reconcileGitCredentials(
+	ctx context.Context,
+	log logr.Logger,
+	system *v1beta1.System,
+) (string, error) {
+	secretName := system.Spec.SourceControl.Origin.CredentialsSecretName
+
+	var k8sSecret corev1.Secret
+	if err := r.Get(ctx, types.NamespacedName{Name: secretName, Namespace: system.Namespace}, &k8sSecret); err != nil {
+		return "", ctrlerr.Wrap(err, fmt.Sprintf("Could not fetch git credentials secret: %s", secretName))
+	}
+
+	value, err := gitCredentialsSecretValue(&k8sSecret,
+		giturl.IsSSH(system.Spec.SourceControl.Origin.URL), r.Config.OPAControlPlaneConfig.SSHHostKeyFingerprints)
 	if err != nil {
-		return false, ctrlerr.Wrap(err, "PutSource in createSourceIfNotExists failed")
+		return "", ctrlerr.Wrap(err, fmt.Sprintf("Invalid git credentials secret: %s", secretName))
 	}
-	log.Info("Source created", "source", source.Path)
 
-	return true, nil
+	secretID := system.GitSecretID(r.Config.SystemPrefix, r.Config.SystemSuffix)
+	if err := r.OCP.PutSecret(ctx, secretID, &ocp.Secret{Name: secretID, Value: value}); err != nil {
+		return "", ctrlerr.Wrap(err, fmt.Sprintf("Could not update git credentials in OCP: %s", secretID))
+	}
+	log.Info("OCP git credentials upserted", "secret", secretID)
+	if system.Status.GitCredentialsSecretID == "" {
+		// Record the secret right away, so that it is deleted with the System
+		// even if the reconcile fails later on.
+		system.Status.GitCredentialsSecretID = secretID
+	}
+
+	return secretID, nil
+}
+
+// gitCredentialsSecretValue converts a Secret holding git credentials to the
+// value of a secret in OCP. Repositories accessed over ssh require an SSH
+// private key, which is verified against the host key fingerprints in the
+// Secret or, when the Secret has none, the given default fingerprints. Other
+// repositories require basic auth credentials.
+func gitCredentialsSecretValue(
+	secret *corev1.Secret,
+	ssh bool,
+	defaultFingerprints []string,
+) (map[string]interface{}, error) {
+	if ssh {
+		key, ok := secret.Data[corev1.SSHAuthPrivateKey]
+		if !ok {
+			return nil, errors.Errorf("secret must contain the key %s for ssh repositories", corev1.SSHAuthPrivateKey)
+		}
+		value := map[string]interface{}{
+			"type": "ssh_key",
+			"key":  string(key),
+		}
+		if passphrase, ok := secret.Data[gitCredentialsPassphraseKey]; ok {
+			value["passphrase"] = string(passphrase)
+		}
+		fingerprints := defaultFingerprints
+		if data, ok := secret.Data[gitCredentialsFingerprintsKey]; ok {
+			fingerprints = strings.Fields(string(data))
+		}
+		if len(fingerprints) > 0 {
+			value["fingerprints"] = slices.Clone(fingerprints)
+		}
+		return value, nil
+	}
+
+	username, hasUsername := secret.Data[gitCredentialsUsernameKey]
+	password, hasPassword := secret.Data[gitCredentialsPasswordKey]
+	if !hasUsername || !hasPassword {
+		return nil, errors.Errorf(
+			"secret must contain the keys %s and %s",
+			gitCredentialsUsernameKey, gitCredentialsPasswordKey,
+		)
+	}
+
+	return map[string]interface{}{
+		"type":     "basic_auth",
+		"username": string(username),
+		"password": string(password),
+	}, nil
+}
+
+func isURLValid(rawURL string) bool {
+	if rawURL == "" {
+		return true
+	}
+
+	return giturl.Valid(rawURL)
 }
 
 // CreateDefaultRequirements creates all the configured default sources in OCP.
 func (r *SystemReconciler) CreateDefaultRequirements(ctx context.Context, log logr.Logger) error {
 	log.Info("Creating OCP default requirements")
 	for _, defaultRequirement := range r.Config.OPAControlPlaneConfig.DefaultRequirements {
-		_, err := r.createSourceIfNotExists(ctx, log, v1beta1.Datasource{Path: defaultRequirement})
+		_, err := createSourceIfNotExists(ctx, log, r.OCP, defaultRequirement)
 		if err != nil {
 			return err
 		}
@@ -837,6 +1398,38 @@ func (r *SystemReconciler) SetupWithManager(mgr ctrl.Manager, name string) error
 	); err != nil {
 		return errors.Wrap(err, "Could not create field index")
 	}
+	if err := mgr.GetFieldIndexer().IndexField(
+		context.Background(),
+		&v1beta1.System{},
+		fields.SystemDatasourceCredentialsSecretName,
+		func(o client.Object) []string {
+			System := o.(*v1beta1.System)
+			var names []string
+			for _, datasource := range System.Spec.Datasources {
+				if datasource.CredentialsSecretName != "" {
+					names = append(names, datasource.CredentialsSecretName)
+				}
+			}
+			return names
+		},
+	); err != nil {
+		return errors.Wrap(err, "Could not create field index")
+	}
+	if err := mgr.GetFieldIndexer().IndexField(
+		context.Background(),
+		&v1beta1.System{},
+		fields.SystemFilesConfigMapName,
+		func(o client.Object) []string {
+			System := o.(*v1beta1.System)
+			var names []string
+			for _, filesFrom := range System.Spec.FilesFrom {
+				names = append(names, filesFrom.ConfigMapName)
+			}
+			return names
+		},
+	); err != nil {
+		return errors.Wrap(err, "Could not create field index")
+	}
 
 	// Setup predicate which ensures that we only reconcile System changes
 	// that match the controller class, and only for changes of the spec
@@ -861,38 +1454,128 @@ func (r *SystemReconciler) SetupWithManager(mgr ctrl.Manager, name string) error
 			handler.EnqueueRequestsFromMapFunc(r.findSystemsForConfigMap),
 			builder.WithPredicates(ctrlpred.ResourceVersionChangedPredicate{}),
 		).
+		Watches(
+			&styrav1alpha1.Library{},
+			r.libraryEventHandler(),
+			builder.WithPredicates(updatedPred),
+		).
 		Complete(r)
 }
 
+// libraryEventHandler enqueues the Systems whose library selector matches a
+// Library before or after it changed, so that Systems both start and stop
+// requiring it.
+func (r *SystemReconciler) libraryEventHandler() handler.EventHandler {
+	return handler.Funcs{
+		CreateFunc: func(
+			ctx context.Context,
+			e event.CreateEvent,
+			q workqueue.TypedRateLimitingInterface[reconcile.Request],
+		) {
+			r.enqueueSystemsForLibraries(ctx, q, e.Object)
+		},
+		UpdateFunc: func(
+			ctx context.Context,
+			e event.UpdateEvent,
+			q workqueue.TypedRateLimitingInterface[reconcile.Request],
+		) {
+			r.enqueueSystemsForLibraries(ctx, q, e.ObjectOld, e.ObjectNew)
+		},
+		DeleteFunc: func(
+			ctx context.Context,
+			e event.DeleteEvent,
+			q workqueue.TypedRateLimitingInterface[reconcile.Request],
+		) {
+			r.enqueueSystemsForLibraries(ctx, q, e.Object)
+		},
+	}
+}
+
+func (r *SystemReconciler) enqueueSystemsForLibraries(
+	ctx context.Context,
+	q workqueue.TypedRateLimitingInterface[reconcile.Request],
+	libraries ...client.Object,
+) {
+	for _, request := range r.findSystemsForLibraries(ctx, libraries...) {
+		q.Add(request)
+	}
+}
+
+// findSystemsForLibraries finds the Systems whose library selector matches any
+// of the given Libraries.
+func (r *SystemReconciler) findSystemsForLibraries(
+	ctx context.Context,
+	libraries ...client.Object,
+) []reconcile.Request {
+	ls, err := labels.ControllerClassLabelSelectorAsSelector(r.Config.ControllerClass)
+	if err != nil {
+		panic(err)
+	}
+
+	var systems v1beta1.SystemList
+	if err := r.List(ctx, &systems, &client.ListOptions{LabelSelector: ls}); err != nil {
+		return []reconcile.Request{}
+	}
+
+	var requests []reconcile.Request
+	for _, system := range systems.Items {
+		if system.Spec.LibrarySelector == nil {
+			continue
+		}
+		selector, err := metav1.LabelSelectorAsSelector(system.Spec.LibrarySelector)
+		if err != nil {
+			continue
+		}
+		for _, library := range libraries {
+			if selector.Matches(k8slabels.Set(library.GetLabels())) {
+				requests = append(requests, reconcile.Request{
+					NamespacedName: types.NamespacedName{
+						Name:      system.GetName(),
+						Namespace: system.GetNamespace(),
+					},
+				})
+				break
+			}
+		}
+	}
+
+	return requests
+}
+
 func (r *SystemReconciler) findSystemsForSecret(ctx context.Context, secret client.Object) []reconcile.Request {
 	requests := r.findSystemsRefferingToSecret(ctx, secret)
 	return append(requests, r.findSecretOwners(ctx, secret)...)
 }
 
-// findSystemsRefferingToSecret detects if modified secret is the secret containing Git credentials for a System.
+// findSystemsRefferingToSecret detects if modified secret is the secret containing Git or datasource credentials
+// for a System.
 func (r *SystemReconciler) findSystemsRefferingToSecret(ctx context.Context, secret client.Object) []reconcile.Request {
-	var systemsWithCredentialsRef v1beta1.SystemList
 	ls, err := labels.ControllerClassLabelSelectorAsSelector(r.Config.ControllerClass)
 	if err != nil {
 		panic(err)
 	}
 
-	if err := r.List(ctx, &systemsWithCredentialsRef, &client.ListOptions{
-		FieldSelector: fields.SystemCredentialsSecretNameSelector(secret.GetName()),
-		LabelSelector: ls,
-		Namespace:     secret.GetNamespace(),
-	}); err != nil {
-		return []reconcile.Request{}
+	names := sets.New[types.NamespacedName]()
+	for _, fieldSelector := range []k8sfields.Selector{
+		fields.SystemCredentialsSecretNameSelector(secret.GetName()),
+		fields.SystemDatasourceCredentialsSecretNameSelector(secret.GetName()),
+	} {
+		var systemsWithCredentialsRef v1beta1.SystemList
+		if err := r.List(ctx, &systemsWithCredentialsRef, &client.ListOptions{
+			FieldSelector: fieldSelector,
+			LabelSelector: ls,
+			Namespace:     secret.GetNamespace(),
+		}); err != nil {
+			return []reconcile.Request{}
+		}
+		for _, item := range systemsWithCredentialsRef.Items {
+			names.Insert(types.NamespacedName{Name: item.GetName(), Namespace: item.GetNamespace()})
+		}
 	}
 
-	requests := make([]reconcile.Request, len(systemsWithCredentialsRef.Items))
-	for i, item := range systemsWithCredentialsRef.Items {
-		requests[i] = reconcile.Request{
-			NamespacedName: types.NamespacedName{
-				Name:      item.GetName(),
-				Namespace: item.GetNamespace(),
-			},
-		}
+	requests := make([]reconcile.Request, 0, names.Len())
+	for name := range names {
+		requests = append(requests, reconcile.Request{NamespacedName: name})
 	}
 
 	return requests
@@ -931,8 +1614,46 @@ func ownerIsSystem(owner metav1.OwnerReference) bool {
 		owner.Kind == "System"
 }
 
-// findSystemsForConfigMap detects if modified configmap is the configmap containing opa/slp config.
+// findSystemsForConfigMap finds the Systems affected by a modified configmap.
 func (r *SystemReconciler) findSystemsForConfigMap(ctx context.Context, configmap client.Object) []reconcile.Request {
+	requests := r.findSystemsWithFilesFromConfigMap(ctx, configmap)
+	return append(requests, r.findConfigMapOwners(ctx, configmap)...)
+}
+
+// findSystemsWithFilesFromConfigMap detects if modified configmap holds files for the source of a System.
+func (r *SystemReconciler) findSystemsWithFilesFromConfigMap(
+	ctx context.Context,
+	configmap client.Object,
+) []reconcile.Request {
+	ls, err := labels.ControllerClassLabelSelectorAsSelector(r.Config.ControllerClass)
+	if err != nil {
+		panic(err)
+	}
+
+	var systems v1beta1.SystemList
+	if err := r.List(ctx, &systems, &client.ListOptions{
+		FieldSelector: fields.SystemFilesConfigMapNameSelector(configmap.GetName()),
+		LabelSelector: ls,
+		Namespace:     configmap.GetNamespace(),
+	}); err != nil {
+		return []reconcile.Request{}
+	}
+
+	requests := make([]reconcile.Request, len(systems.Items))
+	for i, item := range systems.Items {
+		requests[i] = reconcile.Request{
+			NamespacedName: types.NamespacedName{
+				Name:      item.GetName(),
+				Namespace: item.GetNamespace(),
+			},
+		}
+	}
+
+	return requests
+}
+
+// findConfigMapOwners detects if modified configmap is the configmap containing opa/slp config.
+func (r *SystemReconciler) findConfigMapOwners(ctx context.Context, configmap client.Object) []reconcile.Request {
 	var requests []reconcile.Request
 
 	for _, owner := range configmap.GetOwnerReferences() {
@@ -17,12 +17,33 @@ limitations under the License.
 package styra
 
 import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/go-logr/logr"
 	ginkgo "github.com/onsi/ginkgo/v2"
 	gomega "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/mock"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/events"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	configv2alpha2 "github.com/bankdata/styra-controller/api/config/v2alpha2"
+	styrav1alpha1 "github.com/bankdata/styra-controller/api/styra/v1alpha1"
 	"github.com/bankdata/styra-controller/api/styra/v1beta1"
+	"github.com/bankdata/styra-controller/internal/decisionlog"
+	"github.com/bankdata/styra-controller/pkg/httperror"
+	"github.com/bankdata/styra-controller/pkg/ocp"
+	"github.com/bankdata/styra-controller/pkg/ocp/mocks"
+	"github.com/bankdata/styra-controller/pkg/ptr"
 )
 
 // test the isURLValid method
@@ -33,11 +54,14 @@ var _ = ginkgo.DescribeTable("isURLValid",
 	ginkgo.Entry("valid url", "", true),
 	ginkgo.Entry("valid url", "https://www.github.com/test/repo.git", true),
 	ginkgo.Entry("valid url", "https://www.github.com/test/repo", true),
+	ginkgo.Entry("valid url", "ssh://git@github.com/test/repo.git", true),
+	ginkgo.Entry("valid url", "git@github.com:test/repo.git", true),
 	ginkgo.Entry("invalid url", "https://www.github.com/[test]/repo", false),
 	ginkgo.Entry("invalid url", "https://www.github.com/[test]/repo.git", false),
 	ginkgo.Entry("invalid url", "www.google.com", false),
 	ginkgo.Entry("invalid url", "google.com", false),
 	ginkgo.Entry("invalid url", "google", false),
+	ginkgo.Entry("invalid url", "ssh://git@github.com", false),
 )
 
 // test the isSystemNamespaceMatchingSelector method
@@ -69,3 +93,749 @@ var _ = ginkgo.DescribeTable("isSystemNamespaceMatchingSelector",
 	ginkgo.Entry("empty patterns returns true", &configv2alpha2.NamespaceSelector{
 		MatchPatterns: []string{}}, "mynamespace", true),
 )
+
+// test the gitCredentialsSecretValue method
+var _ = ginkgo.DescribeTable("gitCredentialsSecretValue",
+	func(data map[string][]byte, ssh bool, expected map[string]interface{}, expectErr bool) {
+		value, err := gitCredentialsSecretValue(&corev1.Secret{Data: data}, ssh, []string{"SHA256:default"})
+		if expectErr {
+			gomega.Ω(err).To(gomega.HaveOccurred())
+			return
+		}
+		gomega.Ω(err).NotTo(gomega.HaveOccurred())
+		gomega.Ω(value).To(gomega.Equal(expected))
+	},
+	ginkgo.Entry("basic auth", map[string][]byte{
+		"name":   []byte("user"),
+		"secret": []byte("token"),
+	}, false, map[string]interface{}{
+		"type":     "basic_auth",
+		"username": "user",
+		"password": "token",
+	}, false),
+	ginkgo.Entry("ssh key", map[string][]byte{
+		"ssh-privatekey": []byte("key"),
+		"name":           []byte("user"),
+	}, true, map[string]interface{}{
+		"type":         "ssh_key",
+		"key":          "key",
+		"fingerprints": []string{"SHA256:default"},
+	}, false),
+	ginkgo.Entry("ssh key with passphrase", map[string][]byte{
+		"ssh-privatekey": []byte("key"),
+		"passphrase":     []byte("pass"),
+	}, true, map[string]interface{}{
+		"type":         "ssh_key",
+		"key":          "key",
+		"passphrase":   "pass",
+		"fingerprints": []string{"SHA256:default"},
+	}, false),
+	ginkgo.Entry("ssh key with fingerprints", map[string][]byte{
+		"ssh-privatekey": []byte("key"),
+		"fingerprints":   []byte("SHA256:first\nSHA256:second\n"),
+	}, true, map[string]interface{}{
+		"type":         "ssh_key",
+		"key":          "key",
+		"fingerprints": []string{"SHA256:first", "SHA256:second"},
+	}, false),
+	ginkgo.Entry("missing ssh key", map[string][]byte{
+		"name":   []byte("user"),
+		"secret": []byte("token"),
+	}, true, nil, true),
+	ginkgo.Entry("ssh key for https repository", map[string][]byte{
+		"ssh-privatekey": []byte("key"),
+	}, false, nil, true),
+	ginkgo.Entry("missing password", map[string][]byte{
+		"name": []byte("user"),
+	}, false, nil, true),
+)
+
+var _ = ginkgo.DescribeTable("systemGitConfig credentials",
+	func(namespace, url, expectedID string) {
+		r := &SystemReconciler{
+			Config: &configv2alpha2.ProjectConfig{
+				OPAControlPlaneConfig: &configv2alpha2.OPAControlPlaneConfig{
+					GitCredentials: []*configv2alpha2.GitCredentials{
+						{ID: "org", RepoPrefix: "https://github.com/org"},
+						{ID: "team", RepoPrefix: "https://github.com/org/team", Namespaces: []string{"team"}},
+						{ID: "bitbucket", RepoPrefix: "https://bitbucket.org/org", Namespaces: []string{"team"}},
+					},
+				},
+			},
+		}
+		system := &v1beta1.System{
+			ObjectMeta: metav1.ObjectMeta{Name: "system", Namespace: namespace},
+			Spec: v1beta1.SystemSpec{
+				SourceControl: &v1beta1.SourceControl{Origin: v1beta1.GitRepo{URL: url}},
+			},
+		}
+
+		gitConfig, err := r.systemGitConfig(context.Background(), logr.Discard(), system)
+		if expectedID == "" {
+			gomega.Ω(err).To(gomega.HaveOccurred())
+			gomega.Ω(system.GetCondition(v1beta1.ConditionTypeCredentialsResolved)).
+				To(gomega.HaveValue(gomega.Equal(metav1.ConditionFalse)))
+			return
+		}
+		gomega.Ω(err).NotTo(gomega.HaveOccurred())
+		gomega.Ω(gitConfig.Repo).To(gomega.Equal(url))
+		gomega.Ω(gitConfig.CredentialID).To(gomega.Equal(expectedID))
+		gomega.Ω(system.GetCondition(v1beta1.ConditionTypeCredentialsResolved)).
+			To(gomega.HaveValue(gomega.Equal(metav1.ConditionTrue)))
+	},
+	ginkgo.Entry("longest prefix", "team", "git@github.com:org/team/repo.git", "team"),
+	ginkgo.Entry("shorter prefix", "other", "https://github.com/org/repo.git", "org"),
+	ginkgo.Entry("falls back when namespace not allowed", "other", "https://github.com/org/team/repo.git", "org"),
+	ginkgo.Entry("namespace not allowed", "other", "https://bitbucket.org/org/repo.git", ""),
+	ginkgo.Entry("no match", "team", "https://gitlab.com/org/repo.git", ""),
+)
+
+var _ = ginkgo.Describe("detectDrift", func() {
+	var (
+		reconciler *SystemReconciler
+		recorder   *events.FakeRecorder
+		system     *v1beta1.System
+	)
+
+	ginkgo.BeforeEach(func() {
+		recorder = events.NewFakeRecorder(10)
+		reconciler = &SystemReconciler{
+			Recorder: recorder,
+			Metrics: &SystemReconcilerMetrics{
+				DriftDetected: prometheus.NewCounterVec(
+					prometheus.CounterOpts{Name: "drift_detected_total"},
+					[]string{"system_name", "namespace", "resource"},
+				),
+			},
+			Config: &configv2alpha2.ProjectConfig{
+				OPAControlPlaneConfig: &configv2alpha2.OPAControlPlaneConfig{
+					ResyncInterval: metav1.Duration{Duration: time.Minute},
+				},
+			},
+		}
+		system = &v1beta1.System{
+			ObjectMeta: metav1.ObjectMeta{Name: "system", Namespace: "default", Generation: 2},
+			Status:     v1beta1.SystemStatus{ObservedGeneration: 2},
+		}
+	})
+
+	upToDate := func(ok bool) func() (bool, error) {
+		return func() (bool, error) { return ok, nil }
+	}
+
+	ginkgo.It("always applies when drift detection is disabled", func() {
+		reconciler.Config.OPAControlPlaneConfig.ResyncInterval = metav1.Duration{}
+		apply, err := reconciler.detectDrift(logr.Discard(), system, "source", "unique", upToDate(true))
+		gomega.Ω(err).NotTo(gomega.HaveOccurred())
+		gomega.Ω(apply).To(gomega.BeTrue())
+	})
+
+	ginkgo.It("does not apply when OCP is up to date", func() {
+		apply, err := reconciler.detectDrift(logr.Discard(), system, "source", "unique", upToDate(true))
+		gomega.Ω(err).NotTo(gomega.HaveOccurred())
+		gomega.Ω(apply).To(gomega.BeFalse())
+		gomega.Ω(recorder.Events).To(gomega.BeEmpty())
+	})
+
+	ginkgo.It("records drift when an applied generation has drifted", func() {
+		apply, err := reconciler.detectDrift(logr.Discard(), system, "bundle", "unique", upToDate(false))
+		gomega.Ω(err).NotTo(gomega.HaveOccurred())
+		gomega.Ω(apply).To(gomega.BeTrue())
+		gomega.Ω(recorder.Events).To(gomega.Receive(gomega.HavePrefix("Warning DriftDetected")))
+		gomega.Ω(testutil.ToFloat64(
+			reconciler.Metrics.DriftDetected.WithLabelValues("system", "default", "bundle"),
+		)).To(gomega.Equal(1.0))
+	})
+
+	ginkgo.It("does not record drift when the System has changed", func() {
+		system.Generation = 3
+		apply, err := reconciler.detectDrift(logr.Discard(), system, "source", "unique", upToDate(false))
+		gomega.Ω(err).NotTo(gomega.HaveOccurred())
+		gomega.Ω(apply).To(gomega.BeTrue())
+		gomega.Ω(recorder.Events).To(gomega.BeEmpty())
+	})
+})
+
+var _ = ginkgo.Describe("reconcileRemovedDatasources", func() {
+	var (
+		ocpClient  *mocks.ClientInterface
+		reconciler *SystemReconciler
+		system     *v1beta1.System
+	)
+
+	ginkgo.BeforeEach(func() {
+		scheme := runtime.NewScheme()
+		gomega.Expect(v1beta1.AddToScheme(scheme)).To(gomega.Succeed())
+		gomega.Expect(styrav1alpha1.AddToScheme(scheme)).To(gomega.Succeed())
+
+		ocpClient = &mocks.ClientInterface{}
+		reconciler = &SystemReconciler{
+			Client: fake.NewClientBuilder().
+				WithScheme(scheme).
+				WithObjects(&v1beta1.System{
+					ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "default", UID: "other"},
+					Spec: v1beta1.SystemSpec{
+						Datasources: []v1beta1.Datasource{{Path: "shared"}},
+					},
+				}).
+				Build(),
+			OCP:    ocpClient,
+			Config: &configv2alpha2.ProjectConfig{},
+		}
+		system = &v1beta1.System{
+			ObjectMeta: metav1.ObjectMeta{Name: "system", Namespace: "default", UID: "system"},
+			Status:     v1beta1.SystemStatus{DatasourceSources: []string{"kept", "removed"}},
+		}
+	})
+
+	ginkgo.It("deletes the sources of removed datasources", func() {
+		ocpClient.On("DeleteSource", mock.Anything, "removed").Return(nil).Once()
+		ocpClient.On("DeleteSecret", mock.Anything, "removed-credentials").Return(nil).Once()
+
+		err := reconciler.reconcileRemovedDatasources(context.Background(), logr.Discard(), system,
+			[]string{"kept", "added"})
+		gomega.Ω(err).NotTo(gomega.HaveOccurred())
+		gomega.Ω(system.Status.DatasourceSources).To(gomega.Equal([]string{"kept", "added"}))
+		ocpClient.AssertExpectations(ginkgo.GinkgoT())
+	})
+
+	ginkgo.It("keeps the sources of datasources shared with other systems", func() {
+		system.Status.DatasourceSources = append(system.Status.DatasourceSources, "shared")
+		ocpClient.On("DeleteSource", mock.Anything, "removed").Return(nil).Once()
+		ocpClient.On("DeleteSecret", mock.Anything, "removed-credentials").Return(nil).Once()
+
+		err := reconciler.reconcileRemovedDatasources(context.Background(), logr.Discard(), system,
+			[]string{"kept"})
+		gomega.Ω(err).NotTo(gomega.HaveOccurred())
+		gomega.Ω(system.Status.DatasourceSources).To(gomega.Equal([]string{"kept"}))
+		ocpClient.AssertExpectations(ginkgo.GinkgoT())
+		ocpClient.AssertNotCalled(ginkgo.GinkgoT(), "DeleteSource", mock.Anything, "shared")
+	})
+
+	ginkgo.It("keeps the sources when the system is deletion protected", func() {
+		system.Spec.DeletionProtection = ptr.Bool(true)
+
+		err := reconciler.reconcileRemovedDatasources(context.Background(), logr.Discard(), system,
+			[]string{"kept"})
+		gomega.Ω(err).NotTo(gomega.HaveOccurred())
+		gomega.Ω(system.Status.DatasourceSources).To(gomega.Equal([]string{"kept", "removed"}))
+		ocpClient.AssertNotCalled(ginkgo.GinkgoT(), "DeleteSource", mock.Anything, mock.Anything)
+	})
+
+	ginkgo.It("deletes the kept sources once the system is no longer deletion protected", func() {
+		system.Spec.DeletionProtection = ptr.Bool(true)
+		err := reconciler.reconcileRemovedDatasources(context.Background(), logr.Discard(), system,
+			[]string{"kept"})
+		gomega.Ω(err).NotTo(gomega.HaveOccurred())
+
+		system.Spec.DeletionProtection = ptr.Bool(false)
+		ocpClient.On("DeleteSource", mock.Anything, "removed").Return(nil).Once()
+		ocpClient.On("DeleteSecret", mock.Anything, "removed-credentials").Return(nil).Once()
+
+		err = reconciler.reconcileRemovedDatasources(context.Background(), logr.Discard(), system,
+			[]string{"kept"})
+		gomega.Ω(err).NotTo(gomega.HaveOccurred())
+		gomega.Ω(system.Status.DatasourceSources).To(gomega.Equal([]string{"kept"}))
+		ocpClient.AssertExpectations(ginkgo.GinkgoT())
+	})
+
+	ginkgo.It("keeps tracking the sources when a deletion fails", func() {
+		ocpClient.On("DeleteSource", mock.Anything, "removed").Return(errors.New("error")).Once()
+
+		err := reconciler.reconcileRemovedDatasources(context.Background(), logr.Discard(), system,
+			[]string{"kept"})
+		gomega.Ω(err).To(gomega.HaveOccurred())
+		gomega.Ω(system.Status.DatasourceSources).To(gomega.Equal([]string{"kept", "removed"}))
+	})
+})
+
+var _ = ginkgo.Describe("typed datasources shared between systems", func() {
+	var (
+		ocpClient  *mocks.ClientInterface
+		reconciler *SystemReconciler
+		first      *v1beta1.System
+		second     *v1beta1.System
+	)
+
+	users := v1beta1.Datasource{
+		Path:   "users",
+		Type:   "http",
+		Config: &runtime.RawExtension{Raw: []byte(`{"url": "https://users.example.com"}`)},
+	}
+
+	newSystem := func(namespace string, created time.Time) *v1beta1.System {
+		return &v1beta1.System{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              "system",
+				Namespace:         namespace,
+				UID:               types.UID(namespace),
+				CreationTimestamp: metav1.NewTime(created),
+			},
+			Spec: v1beta1.SystemSpec{Datasources: []v1beta1.Datasource{users}},
+		}
+	}
+
+	ginkgo.BeforeEach(func() {
+		scheme := runtime.NewScheme()
+		gomega.Expect(v1beta1.AddToScheme(scheme)).To(gomega.Succeed())
+
+		created := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+		first = newSystem("team-a", created)
+		second = newSystem("team-b", created.Add(time.Hour))
+
+		ocpClient = &mocks.ClientInterface{}
+		reconciler = &SystemReconciler{
+			Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(first, second).Build(),
+			OCP:    ocpClient,
+			Config: &configv2alpha2.ProjectConfig{},
+		}
+	})
+
+	ginkgo.It("reconciles the datasource of the system which declared it first", func() {
+		ocpClient.On("PutSource", mock.Anything, "users", mock.Anything).Return(&ocp.PutSourceResponse{}, nil).Once()
+
+		changed, err := reconciler.reconcileDatasourceSource(context.Background(), logr.Discard(), first, users)
+		gomega.Ω(err).NotTo(gomega.HaveOccurred())
+		gomega.Ω(changed).To(gomega.BeTrue())
+		ocpClient.AssertExpectations(ginkgo.GinkgoT())
+	})
+
+	ginkgo.It("does not overwrite the datasource from another system with the same path", func() {
+		_, err := reconciler.reconcileDatasourceSource(context.Background(), logr.Discard(), second, users)
+		gomega.Ω(err).To(gomega.MatchError(gomega.ContainSubstring("already defined by System team-a/system")))
+		ocpClient.AssertNotCalled(ginkgo.GinkgoT(), "PutSource", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	ginkgo.It("allows systems to declare the datasource without a type", func() {
+		untyped := v1beta1.Datasource{Path: "users"}
+		ocpClient.On("GetSource", mock.Anything, "users").Return(&ocp.GetSourceResponse{}, nil).Once()
+
+		changed, err := reconciler.reconcileDatasourceSource(context.Background(), logr.Discard(), second, untyped)
+		gomega.Ω(err).NotTo(gomega.HaveOccurred())
+		gomega.Ω(changed).To(gomega.BeFalse())
+	})
+})
+
+var _ = ginkgo.Describe("git credentials secret tracking", func() {
+	var (
+		ocpClient  *mocks.ClientInterface
+		reconciler *SystemReconciler
+		system     *v1beta1.System
+	)
+
+	ginkgo.BeforeEach(func() {
+		ocpClient = &mocks.ClientInterface{}
+		reconciler = &SystemReconciler{
+			OCP:    ocpClient,
+			Config: &configv2alpha2.ProjectConfig{},
+		}
+		system = &v1beta1.System{
+			ObjectMeta: metav1.ObjectMeta{Name: "system", Namespace: "default"},
+			Spec: v1beta1.SystemSpec{
+				SourceControl: &v1beta1.SourceControl{Origin: v1beta1.GitRepo{
+					URL:                   "https://github.com/org/repo.git",
+					CredentialsSecretName: "git-credentials",
+				}},
+			},
+			Status: v1beta1.SystemStatus{GitCredentialsSecretID: "default-system-git"},
+		}
+	})
+
+	ginkgo.It("keeps the secret while it is referenced", func() {
+		err := reconciler.reconcileRemovedGitCredentials(context.Background(), logr.Discard(), system)
+		gomega.Ω(err).NotTo(gomega.HaveOccurred())
+		gomega.Ω(system.Status.GitCredentialsSecretID).To(gomega.Equal("default-system-git"))
+		ocpClient.AssertNotCalled(ginkgo.GinkgoT(), "DeleteSecret", mock.Anything, mock.Anything)
+	})
+
+	ginkgo.It("deletes the secret when the reference is removed from the spec", func() {
+		system.Spec.SourceControl.Origin.CredentialsSecretName = ""
+		ocpClient.On("DeleteSecret", mock.Anything, "default-system-git").Return(nil).Once()
+
+		err := reconciler.reconcileRemovedGitCredentials(context.Background(), logr.Discard(), system)
+		gomega.Ω(err).NotTo(gomega.HaveOccurred())
+		gomega.Ω(system.Status.GitCredentialsSecretID).To(gomega.BeEmpty())
+		ocpClient.AssertExpectations(ginkgo.GinkgoT())
+	})
+
+	ginkgo.It("deletes the old secret when the unique name changes", func() {
+		reconciler.Config.SystemPrefix = "cluster"
+		ocpClient.On("DeleteSecret", mock.Anything, "default-system-git").Return(nil).Once()
+
+		err := reconciler.reconcileRemovedGitCredentials(context.Background(), logr.Discard(), system)
+		gomega.Ω(err).NotTo(gomega.HaveOccurred())
+		gomega.Ω(system.Status.GitCredentialsSecretID).To(gomega.Equal("cluster-default-system-git"))
+		ocpClient.AssertExpectations(ginkgo.GinkgoT())
+	})
+
+	ginkgo.It("keeps tracking the secret when the deletion fails", func() {
+		system.Spec.SourceControl = nil
+		ocpClient.On("DeleteSecret", mock.Anything, "default-system-git").Return(errors.New("error")).Once()
+
+		err := reconciler.reconcileRemovedGitCredentials(context.Background(), logr.Discard(), system)
+		gomega.Ω(err).To(gomega.HaveOccurred())
+		gomega.Ω(system.Status.GitCredentialsSecretID).To(gomega.Equal("default-system-git"))
+	})
+
+	ginkgo.It("deletes the tracked secret with the System after the reference is removed", func() {
+		system.Spec.SourceControl.Origin.CredentialsSecretName = ""
+		gomega.Ω(reconciler.gitCredentialsSecretIDs(system)).To(gomega.Equal([]string{"default-system-git"}))
+
+		system.Status.GitCredentialsSecretID = ""
+		gomega.Ω(reconciler.gitCredentialsSecretIDs(system)).To(gomega.BeEmpty())
+	})
+})
+
+var _ = ginkgo.Describe("systemEmbeddedFiles", func() {
+	var reconciler *SystemReconciler
+
+	ginkgo.BeforeEach(func() {
+		scheme := runtime.NewScheme()
+		gomega.Expect(corev1.AddToScheme(scheme)).To(gomega.Succeed())
+
+		reconciler = &SystemReconciler{
+			Client: fake.NewClientBuilder().
+				WithScheme(scheme).
+				WithObjects(&corev1.ConfigMap{
+					ObjectMeta: metav1.ObjectMeta{Name: "policy", Namespace: "default"},
+					Data:       map[string]string{"rules.rego": "package rules", "data.json": "{}"},
+				}).
+				Build(),
+		}
+	})
+
+	ginkgo.It("merges inline files, ConfigMap files and the mask policy", func() {
+		system := &v1beta1.System{
+			ObjectMeta: metav1.ObjectMeta{Name: "system", Namespace: "default"},
+			Spec: v1beta1.SystemSpec{
+				Files:            map[string]string{"main.rego": "package main"},
+				FilesFrom:        []v1beta1.FilesFromSource{{ConfigMapName: "policy", Path: "policy"}},
+				DecisionMappings: []v1beta1.DecisionMapping{{Name: "main/allow"}},
+			},
+		}
+
+		files, err := reconciler.systemEmbeddedFiles(context.Background(), system)
+		gomega.Ω(err).NotTo(gomega.HaveOccurred())
+		gomega.Ω(files).To(gomega.HaveLen(4))
+		gomega.Ω(files).To(gomega.HaveKeyWithValue("main.rego", "package main"))
+		gomega.Ω(files).To(gomega.HaveKeyWithValue("policy/rules.rego", "package rules"))
+		gomega.Ω(files).To(gomega.HaveKeyWithValue("policy/data.json", "{}"))
+		gomega.Ω(files).To(gomega.HaveKey(decisionlog.MaskPolicyFile))
+	})
+
+	ginkgo.It("fails when a file is defined more than once", func() {
+		system := &v1beta1.System{
+			ObjectMeta: metav1.ObjectMeta{Name: "system", Namespace: "default"},
+			Spec: v1beta1.SystemSpec{
+				Files:     map[string]string{"rules.rego": "package main"},
+				FilesFrom: []v1beta1.FilesFromSource{{ConfigMapName: "policy"}},
+			},
+		}
+
+		_, err := reconciler.systemEmbeddedFiles(context.Background(), system)
+		gomega.Ω(err).To(gomega.HaveOccurred())
+	})
+
+	ginkgo.It("fails when the ConfigMap does not exist", func() {
+		system := &v1beta1.System{
+			ObjectMeta: metav1.ObjectMeta{Name: "system", Namespace: "default"},
+			Spec: v1beta1.SystemSpec{
+				FilesFrom: []v1beta1.FilesFromSource{{ConfigMapName: "missing"}},
+			},
+		}
+
+		_, err := reconciler.systemEmbeddedFiles(context.Background(), system)
+		gomega.Ω(err).To(gomega.HaveOccurred())
+	})
+
+	ginkgo.It("returns nil without files", func() {
+		files, err := reconciler.systemEmbeddedFiles(context.Background(), &v1beta1.System{})
+		gomega.Ω(err).NotTo(gomega.HaveOccurred())
+		gomega.Ω(files).To(gomega.BeNil())
+	})
+})
+
+var _ = ginkgo.DescribeTable("bundleRevision",
+	func(filesHash string, expected string) {
+		gomega.Ω(bundleRevision("system", nil, ocp.ToRequirements([]string{"ds"}), filesHash)).
+			To(gomega.Equal(expected))
+	},
+	ginkgo.Entry("git source", "",
+		`$"data:{crypto.sha256(concat("", [input.sources["ds"].sql.hash]))},`+
+			`git-sha:{input.sources["system"].git.commit},`+
+			`libraries:{crypto.sha256(concat("", []))}"`),
+	ginkgo.Entry("source with files", "abc",
+		`$"data:{crypto.sha256(concat("", [input.sources["ds"].sql.hash]))},`+
+			`files:abc,`+
+			`libraries:{crypto.sha256(concat("", []))}"`),
+)
+
+var _ = ginkgo.DescribeTable("ocpDeltaBundles",
+	func(config *configv2alpha2.OPAControlPlaneConfig, enableDeltaBundles *bool, expected bool) {
+		reconciler := &SystemReconciler{
+			Config: &configv2alpha2.ProjectConfig{OPAControlPlaneConfig: config},
+		}
+		system := &v1beta1.System{Spec: v1beta1.SystemSpec{EnableDeltaBundles: enableDeltaBundles}}
+		gomega.Ω(reconciler.ocpDeltaBundles(system)).To(gomega.Equal(expected))
+	},
+	ginkgo.Entry("no OCP config", nil, ptr.Bool(true), false),
+	ginkgo.Entry("not enabled in the config",
+		&configv2alpha2.OPAControlPlaneConfig{}, ptr.Bool(true), false),
+	ginkgo.Entry("enabled in the config and the system",
+		&configv2alpha2.OPAControlPlaneConfig{DeltaBundles: true}, ptr.Bool(true), true),
+	ginkgo.Entry("enabled in the config but not the system",
+		&configv2alpha2.OPAControlPlaneConfig{DeltaBundles: true}, nil, false),
+)
+
+var _ = ginkgo.Describe("library selector", func() {
+	var (
+		reconciler *SystemReconciler
+		system     *v1beta1.System
+	)
+
+	library := func(name string, libraryLabels map[string]string) *styrav1alpha1.Library {
+		return &styrav1alpha1.Library{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Labels: libraryLabels},
+			Spec:       styrav1alpha1.LibrarySpec{Name: name + "-source"},
+		}
+	}
+
+	ginkgo.BeforeEach(func() {
+		scheme := runtime.NewScheme()
+		gomega.Expect(v1beta1.AddToScheme(scheme)).To(gomega.Succeed())
+		gomega.Expect(styrav1alpha1.AddToScheme(scheme)).To(gomega.Succeed())
+
+		now := metav1.Now()
+		deleting := library("deleting", map[string]string{"team": "a"})
+		deleting.DeletionTimestamp = &now
+		deleting.Finalizers = []string{"test"}
+
+		system = &v1beta1.System{
+			ObjectMeta: metav1.ObjectMeta{Name: "system", Namespace: "default"},
+			Spec: v1beta1.SystemSpec{
+				LibrarySelector: &metav1.LabelSelector{MatchLabels: map[string]string{"team": "a"}},
+			},
+		}
+
+		reconciler = &SystemReconciler{
+			Client: fake.NewClientBuilder().
+				WithScheme(scheme).
+				WithObjects(
+					system,
+					&v1beta1.System{ObjectMeta: metav1.ObjectMeta{Name: "no-selector", Namespace: "default"}},
+					library("b", map[string]string{"team": "a"}),
+					library("a", map[string]string{"team": "a"}),
+					library("other-team", map[string]string{"team": "b"}),
+					library("other-class", map[string]string{"team": "a", "styra-controller/class": "other"}),
+					deleting,
+				).
+				Build(),
+			Config: &configv2alpha2.ProjectConfig{},
+		}
+	})
+
+	ginkgo.It("selects the sources of the matching libraries", func() {
+		sources, err := reconciler.selectedLibrarySources(context.Background(), system)
+		gomega.Ω(err).NotTo(gomega.HaveOccurred())
+		gomega.Ω(sources).To(gomega.Equal([]string{"a-source", "b-source"}))
+	})
+
+	ginkgo.It("selects nothing without a selector", func() {
+		sources, err := reconciler.selectedLibrarySources(context.Background(), &v1beta1.System{})
+		gomega.Ω(err).NotTo(gomega.HaveOccurred())
+		gomega.Ω(sources).To(gomega.BeEmpty())
+	})
+
+	ginkgo.It("finds the systems selecting a library before or after it changed", func() {
+		expected := []reconcile.Request{{
+			NamespacedName: types.NamespacedName{Name: "system", Namespace: "default"},
+		}}
+		gomega.Ω(reconciler.findSystemsForLibraries(context.Background(),
+			library("a", map[string]string{"team": "a"}))).To(gomega.Equal(expected))
+		gomega.Ω(reconciler.findSystemsForLibraries(context.Background(),
+			library("a", map[string]string{"team": "a"}),
+			library("a", map[string]string{"team": "b"}))).To(gomega.Equal(expected))
+		gomega.Ω(reconciler.findSystemsForLibraries(context.Background(),
+			library("a", map[string]string{"team": "b"}))).To(gomega.BeEmpty())
+	})
+})
+
+var _ = ginkgo.Describe("reconciling without metrics", func() {
+	var scheme *runtime.Scheme
+
+	ginkgo.BeforeEach(func() {
+		scheme = runtime.NewScheme()
+		gomega.Expect(v1beta1.AddToScheme(scheme)).To(gomega.Succeed())
+		gomega.Expect(styrav1alpha1.AddToScheme(scheme)).To(gomega.Succeed())
+	})
+
+	ginkgo.It("does not record metrics for systems", func() {
+		c := fake.NewClientBuilder().WithScheme(scheme).Build()
+		reconciler := &SystemReconciler{Client: c, APIReader: c, Config: &configv2alpha2.ProjectConfig{}}
+
+		_, err := reconciler.Reconcile(context.Background(), reconcile.Request{
+			NamespacedName: types.NamespacedName{Name: "system", Namespace: "default"},
+		})
+		gomega.Ω(err).NotTo(gomega.HaveOccurred())
+		gomega.Ω(func() { reconciler.observeSegmentTime("segment", time.Now()) }).NotTo(gomega.Panic())
+	})
+
+	ginkgo.It("does not record metrics for libraries", func() {
+		reconciler := &LibraryReconciler{
+			Client: fake.NewClientBuilder().WithScheme(scheme).Build(),
+			Config: &configv2alpha2.ProjectConfig{},
+		}
+
+		_, err := reconciler.Reconcile(context.Background(), reconcile.Request{
+			NamespacedName: types.NamespacedName{Name: "library"},
+		})
+		gomega.Ω(err).NotTo(gomega.HaveOccurred())
+		gomega.Ω(func() { reconciler.observeSegmentTime("segment", time.Now()) }).NotTo(gomega.Panic())
+	})
+})
+
+var _ = ginkgo.Describe("periodic resync", func() {
+	var (
+		ctx        context.Context
+		ocpClient  *mocks.ClientInterface
+		recorder   *events.FakeRecorder
+		reconciler *SystemReconciler
+		key        types.NamespacedName
+		bundles    map[string]*ocp.PutBundleRequest
+	)
+
+	ginkgo.BeforeEach(func() {
+		ctx = context.Background()
+		scheme := runtime.NewScheme()
+		gomega.Expect(corev1.AddToScheme(scheme)).To(gomega.Succeed())
+		gomega.Expect(v1beta1.AddToScheme(scheme)).To(gomega.Succeed())
+
+		system := &v1beta1.System{
+			ObjectMeta: metav1.ObjectMeta{Name: "system", Namespace: "default", Generation: 1},
+			Spec:       v1beta1.SystemSpec{Files: map[string]string{"policy.rego": "package policy\n"}},
+		}
+		key = types.NamespacedName{Name: system.Name, Namespace: system.Namespace}
+		c := fake.NewClientBuilder().
+			WithScheme(scheme).
+			WithObjects(system).
+			WithStatusSubresource(system).
+			Build()
+
+		sources := map[string]*ocp.PutSourceRequest{}
+		bundles = map[string]*ocp.PutBundleRequest{}
+		ocpClient = &mocks.ClientInterface{}
+		ocpClient.On("PutSource", mock.Anything, mock.Anything, mock.Anything).
+			Run(func(args mock.Arguments) {
+				sources[args.String(1)] = args.Get(2).(*ocp.PutSourceRequest)
+			}).Return(&ocp.PutSourceResponse{}, nil)
+		ocpClient.On("GetSource", mock.Anything, mock.Anything).
+			Return(func(_ context.Context, id string) (*ocp.GetSourceResponse, error) {
+				source, ok := sources[id]
+				if !ok {
+					return nil, httperror.NewHTTPError(http.StatusNotFound, "")
+				}
+				var config ocp.SourceConfig
+				gomega.Expect(normalizeJSON(source, &config)).To(gomega.Succeed())
+				return &ocp.GetSourceResponse{Source: &config}, nil
+			})
+		ocpClient.On("PutBundle", mock.Anything, mock.Anything).
+			Run(func(args mock.Arguments) {
+				bundle := args.Get(1).(*ocp.PutBundleRequest)
+				bundles[bundle.Name] = bundle
+			}).Return(nil)
+		ocpClient.On("GetBundle", mock.Anything, mock.Anything).
+			Return(func(_ context.Context, name string) (*ocp.GetBundleResponse, error) {
+				bundle, ok := bundles[name]
+				if !ok {
+					return nil, httperror.NewHTTPError(http.StatusNotFound, "")
+				}
+				config := ocp.BundleConfig{Name: name}
+				gomega.Expect(normalizeJSON(bundle, &config)).To(gomega.Succeed())
+				return &ocp.GetBundleResponse{Bundle: &config}, nil
+			})
+
+		recorder = events.NewFakeRecorder(10)
+		reconciler = &SystemReconciler{
+			Client:    c,
+			APIReader: c,
+			Scheme:    scheme,
+			OCP:       ocpClient,
+			Recorder:  recorder,
+			Config: &configv2alpha2.ProjectConfig{
+				OPAControlPlaneConfig: &configv2alpha2.OPAControlPlaneConfig{
+					ResyncInterval: metav1.Duration{Duration: time.Minute},
+					BundleObjectStorage: &configv2alpha2.BundleObjectStorage{
+						S3: &configv2alpha2.S3ObjectStorage{Bucket: "bundles", Region: "eu-west-1"},
+					},
+				},
+				OPA: configv2alpha2.OPAConfig{
+					BundleServer:      &configv2alpha2.OPABundleServer{URL: "https://s3.example.com"},
+					DecisionAPIConfig: &configv2alpha2.DecisionAPIConfig{},
+				},
+			},
+		}
+
+		// The OPA Secret and ConfigMap are created in the first reconciles,
+		// after which the System is reconciled.
+		gomega.Eventually(func() []string {
+			reconcileSystem(ctx, reconciler, key)
+			return drainEvents(recorder)
+		}).WithTimeout(time.Second).Should(gomega.ContainElement(gomega.HavePrefix("Normal ReconciliationCompleted")))
+	})
+
+	ginkgo.It("does not emit events or update the status when nothing changed", func() {
+		var before v1beta1.System
+		gomega.Expect(reconciler.Get(ctx, key, &before)).To(gomega.Succeed())
+
+		result := reconcileSystem(ctx, reconciler, key)
+		gomega.Ω(result.RequeueAfter).To(gomega.Equal(time.Minute))
+		gomega.Ω(drainEvents(recorder)).To(gomega.BeEmpty())
+
+		var after v1beta1.System
+		gomega.Expect(reconciler.Get(ctx, key, &after)).To(gomega.Succeed())
+		gomega.Ω(after.ResourceVersion).To(gomega.Equal(before.ResourceVersion))
+	})
+
+	ginkgo.It("reports the reconcile when drift was corrected", func() {
+		delete(bundles, "default-system")
+
+		reconcileSystem(ctx, reconciler, key)
+		gomega.Ω(drainEvents(recorder)).To(gomega.ConsistOf(
+			gomega.HavePrefix("Warning DriftDetected"),
+			gomega.HavePrefix("Normal ReconciliationCompleted"),
+		))
+	})
+
+	ginkgo.It("reports the reconcile when the System changed", func() {
+		var system v1beta1.System
+		gomega.Expect(reconciler.Get(ctx, key, &system)).To(gomega.Succeed())
+		system.Spec.Files["other.rego"] = "package other\n"
+		system.Generation++
+		gomega.Expect(reconciler.Update(ctx, &system)).To(gomega.Succeed())
+
+		reconcileSystem(ctx, reconciler, key)
+		gomega.Ω(drainEvents(recorder)).To(gomega.ConsistOf(gomega.HavePrefix("Normal ReconciliationCompleted")))
+	})
+})
+
+// reconcileSystem runs the OCP reconcile of the System with the given key.
+func reconcileSystem(ctx context.Context, reconciler *SystemReconciler, key types.NamespacedName) reconcile.Result {
+	var system v1beta1.System
+	gomega.Expect(reconciler.Get(ctx, key, &system)).To(gomega.Succeed())
+	result, err := reconciler.ocpReconcile(ctx, logr.Discard(), &system)
+	gomega.Expect(err).NotTo(gomega.HaveOccurred())
+	return result
+}
+
+// drainEvents returns the events recorded since it was last called.
+func drainEvents(recorder *events.FakeRecorder) []string {
+	var recorded []string
+	for {
+		select {
+		case event := <-recorder.Events:
+			recorded = append(recorded, event)
+		default:
+			return recorded
+		}
+	}
+}
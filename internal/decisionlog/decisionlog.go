@@ -0,0 +1,148 @@
+/*
+Copyright (C) 2025 Bankdata (bankdata@bankdata.dk)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package decisionlog contains helpers for turning the decision mappings of a
+// System into OPA decision log configuration.
+package decisionlog
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/bankdata/styra-controller/api/styra/v1beta1"
+)
+
+const (
+	// MaskPolicyFile is the path of the generated mask policy in the System
+	// source.
+	MaskPolicyFile = "system/log/mask.rego"
+
+	// MaskDecision is the decision OPA evaluates to mask decision log events.
+	MaskDecision = "/system/log/mask"
+
+	// MappedField is the JSON pointer in the decision log event where the
+	// mapped decision fields are written.
+	MappedField = "/input/decision_mapping"
+)
+
+const maskPolicyTemplate = `# Code generated by styra-controller. DO NOT EDIT.
+package system.log
+
+import rego.v1
+
+mappings := %s
+
+mapping := mappings[input.path]
+
+mapping := mappings[""] if not mappings[input.path]
+
+decision_mapping["allowed"] := value if {
+	actual := object.get(input, mapping.allowed.path, null)
+	value := neq(equal(actual, mapping.allowed.expected), mapping.allowed.negated)
+}
+
+decision_mapping["reason"] := object.get(input, mapping.reason, null) if mapping.reason
+
+decision_mapping["columns"] := {col.key: object.get(input, col.path, null) | some col in mapping.columns} if {
+	mapping.columns
+}
+
+mask contains {"op": "upsert", "path": %q, "value": decision_mapping} if mapping
+`
+
+type mapping struct {
+	Allowed *allowed `json:"allowed,omitempty"`
+	Reason  []string `json:"reason,omitempty"`
+	Columns []column `json:"columns,omitempty"`
+}
+
+type allowed struct {
+	Path     []string    `json:"path"`
+	Expected interface{} `json:"expected"`
+	Negated  bool        `json:"negated"`
+}
+
+type column struct {
+	Key  string   `json:"key"`
+	Path []string `json:"path"`
+}
+
+// MaskPolicy generates a Rego mask policy which adds the fields described by
+// the decision mappings to each decision log event. Decisions are matched on
+// the name of the mapping, and a mapping with an empty name is used for
+// decisions without a mapping of their own. An empty string is returned when
+// there are no decision mappings.
+func MaskPolicy(decisionMappings []v1beta1.DecisionMapping) (string, error) {
+	if len(decisionMappings) == 0 {
+		return "", nil
+	}
+
+	mappings := make(map[string]mapping, len(decisionMappings))
+	for _, dm := range decisionMappings {
+		m := mapping{
+			Reason: splitPath(dm.Reason.Path),
+		}
+		if dm.Allowed != nil {
+			expected := v1beta1.Expected{}
+			if dm.Allowed.Expected != nil {
+				expected = *dm.Allowed.Expected
+			}
+			m.Allowed = &allowed{
+				Path:     splitPath(dm.Allowed.Path),
+				Expected: expected.Value(),
+				Negated:  dm.Allowed.Negated,
+			}
+		}
+		for _, col := range dm.Columns {
+			m.Columns = append(m.Columns, column{
+				Key:  col.Key,
+				Path: splitPath(col.Path),
+			})
+		}
+		mappings[dm.Name] = m
+	}
+
+	bs, err := json.MarshalIndent(mappings, "", "\t")
+	if err != nil {
+		return "", errors.Wrap(err, "could not marshal decision mappings")
+	}
+
+	return fmt.Sprintf(maskPolicyTemplate, string(bs), MappedField), nil
+}
+
+// EmbeddedFiles returns the files which should be embedded in the System
+// source for the given decision mappings. nil is returned when there are no
+// decision mappings.
+func EmbeddedFiles(decisionMappings []v1beta1.DecisionMapping) (map[string]string, error) {
+	policy, err := MaskPolicy(decisionMappings)
+	if err != nil {
+		return nil, err
+	}
+	if policy == "" {
+		return nil, nil
+	}
+	return map[string]string{MaskPolicyFile: policy}, nil
+}
+
+func splitPath(path string) []string {
+	if path == "" {
+		return nil
+	}
+	return strings.Split(path, ".")
+}
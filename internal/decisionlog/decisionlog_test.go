@@ -0,0 +1,170 @@
+/*
+Copyright (C) 2025 Bankdata (bankdata@bankdata.dk)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package decisionlog_test
+
+import (
+	"context"
+
+	ginkgo "github.com/onsi/ginkgo/v2"
+	gomega "github.com/onsi/gomega"
+	"github.com/open-policy-agent/opa/v1/ast"
+	"github.com/open-policy-agent/opa/v1/rego"
+
+	"github.com/bankdata/styra-controller/api/styra/v1beta1"
+	"github.com/bankdata/styra-controller/internal/decisionlog"
+	"github.com/bankdata/styra-controller/pkg/ptr"
+)
+
+var _ = ginkgo.Describe("MaskPolicy", func() {
+	ginkgo.It("returns nothing when there are no decision mappings", func() {
+		policy, err := decisionlog.MaskPolicy(nil)
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		gomega.Expect(policy).To(gomega.BeEmpty())
+
+		files, err := decisionlog.EmbeddedFiles(nil)
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		gomega.Expect(files).To(gomega.BeNil())
+	})
+
+	ginkgo.It("generates a mask policy from the decision mappings", func() {
+		files, err := decisionlog.EmbeddedFiles([]v1beta1.DecisionMapping{
+			{
+				Name: "path/to/rule",
+				Allowed: &v1beta1.AllowedMapping{
+					Path:     "result.allowed",
+					Expected: &v1beta1.Expected{String: ptr.String("yes")},
+					Negated:  true,
+				},
+				Reason: v1beta1.ReasonMapping{Path: "result.reasons"},
+				Columns: []v1beta1.ColumnMapping{
+					{Key: "extra", Path: "input.extra"},
+				},
+			},
+			{
+				Allowed: &v1beta1.AllowedMapping{Path: "result"},
+			},
+		})
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		gomega.Expect(files).To(gomega.HaveLen(1))
+
+		policy := files[decisionlog.MaskPolicyFile]
+		gomega.Expect(policy).To(gomega.ContainSubstring("package system.log\n"))
+		gomega.Expect(policy).To(gomega.ContainSubstring(`mappings := {
+	"": {
+		"allowed": {
+			"path": [
+				"result"
+			],
+			"expected": true,
+			"negated": false
+		}
+	},
+	"path/to/rule": {
+		"allowed": {
+			"path": [
+				"result",
+				"allowed"
+			],
+			"expected": "yes",
+			"negated": true
+		},
+		"reason": [
+			"result",
+			"reasons"
+		],
+		"columns": [
+			{
+				"key": "extra",
+				"path": [
+					"input",
+					"extra"
+				]
+			}
+		]
+	}
+}`))
+		gomega.Expect(policy).To(gomega.ContainSubstring(
+			`mask contains {"op": "upsert", "path": "/input/decision_mapping", "value": decision_mapping} if mapping`,
+		))
+	})
+})
+
+var _ = ginkgo.Describe("mask policy evaluation", func() {
+	var compiler *ast.Compiler
+
+	ginkgo.BeforeEach(func() {
+		policy, err := decisionlog.MaskPolicy([]v1beta1.DecisionMapping{
+			{
+				Name: "path/to/rule",
+				Allowed: &v1beta1.AllowedMapping{
+					Path:     "result.allowed",
+					Expected: &v1beta1.Expected{String: ptr.String("yes")},
+					Negated:  true,
+				},
+				Reason: v1beta1.ReasonMapping{Path: "result.reasons"},
+				Columns: []v1beta1.ColumnMapping{
+					{Key: "extra", Path: "input.extra"},
+				},
+			},
+			{
+				Allowed: &v1beta1.AllowedMapping{Path: "result"},
+			},
+		})
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+		compiler, err = ast.CompileModules(map[string]string{decisionlog.MaskPolicyFile: policy})
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+	})
+
+	mask := func(event map[string]interface{}) interface{} {
+		rs, err := rego.New(
+			rego.Compiler(compiler),
+			rego.Query("data.system.log.mask"),
+			rego.Input(event),
+		).Eval(context.Background())
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		gomega.Expect(rs).To(gomega.HaveLen(1))
+		return rs[0].Expressions[0].Value
+	}
+
+	ginkgo.It("masks decisions with the mapping of their path", func() {
+		gomega.Expect(mask(map[string]interface{}{
+			"path":   "path/to/rule",
+			"input":  map[string]interface{}{"extra": "value"},
+			"result": map[string]interface{}{"allowed": "yes", "reasons": []interface{}{"denied by rule"}},
+		})).To(gomega.ConsistOf(map[string]interface{}{
+			"op":   "upsert",
+			"path": decisionlog.MappedField,
+			"value": map[string]interface{}{
+				"allowed": false,
+				"reason":  []interface{}{"denied by rule"},
+				"columns": map[string]interface{}{"extra": "value"},
+			},
+		}))
+	})
+
+	ginkgo.It("masks other decisions with the default mapping", func() {
+		gomega.Expect(mask(map[string]interface{}{
+			"path":   "other/rule",
+			"result": true,
+		})).To(gomega.ConsistOf(map[string]interface{}{
+			"op":    "upsert",
+			"path":  decisionlog.MappedField,
+			"value": map[string]interface{}{"allowed": true},
+		}))
+	})
+})
@@ -20,6 +20,7 @@ package errors
 import (
 	"github.com/pkg/errors"
 
+	"github.com/bankdata/styra-controller/api/styra/v1alpha1"
 	"github.com/bankdata/styra-controller/api/styra/v1beta1"
 )
 
@@ -58,6 +59,18 @@ func (err *ReconcilerErr) WithSystemCondition(contype v1beta1.ConditionType) *Re
 	return err
 }
 
+// WithLibraryEvent adds Library event metadata to the ReconcilerErr.
+func (err *ReconcilerErr) WithLibraryEvent(event v1alpha1.EventType) *ReconcilerErr {
+	err.Event = string(event)
+	return err
+}
+
+// WithLibraryCondition adds Library condition metadata to the ReconcilerErr.
+func (err *ReconcilerErr) WithLibraryCondition(contype v1alpha1.ConditionType) *ReconcilerErr {
+	err.ConditionType = string(contype)
+	return err
+}
+
 // Error implements the error interface.
 func (err *ReconcilerErr) Error() string {
 	return err.err.Error()
@@ -23,6 +23,14 @@ import "k8s.io/apimachinery/pkg/fields"
 const (
 	// SystemCredentialsSecretName is the path to the credential secret name.
 	SystemCredentialsSecretName = ".spec.sourceControl.origin.credentialsSecretName"
+
+	// SystemDatasourceCredentialsSecretName is the path to the credential
+	// secret names of the datasources.
+	SystemDatasourceCredentialsSecretName = ".spec.datasources.credentialsSecretName"
+
+	// SystemFilesConfigMapName is the path to the names of the ConfigMaps
+	// holding files for the source.
+	SystemFilesConfigMapName = ".spec.filesFrom.configMapName"
 )
 
 // SystemCredentialsSecretNameSelector returns a field selector for finding the
@@ -30,3 +38,15 @@ const (
 func SystemCredentialsSecretNameSelector(name string) fields.Selector {
 	return fields.OneTermEqualSelector(SystemCredentialsSecretName, name)
 }
+
+// SystemDatasourceCredentialsSecretNameSelector returns a field selector for
+// finding the Systems with datasources referencing a secret.
+func SystemDatasourceCredentialsSecretNameSelector(name string) fields.Selector {
+	return fields.OneTermEqualSelector(SystemDatasourceCredentialsSecretName, name)
+}
+
+// SystemFilesConfigMapNameSelector returns a field selector for finding the
+// Systems with files from a ConfigMap.
+func SystemFilesConfigMapNameSelector(name string) fields.Selector {
+	return fields.OneTermEqualSelector(SystemFilesConfigMapName, name)
+}
@@ -0,0 +1,86 @@
+/*
+Copyright (C) 2025 Bankdata (bankdata@bankdata.dk)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package gitcredentials resolves which of the git credentials configured for
+// OPA Control Plane a repository is fetched with.
+package gitcredentials
+
+import (
+	"cmp"
+	"path/filepath"
+	"slices"
+
+	"github.com/pkg/errors"
+
+	configv2alpha2 "github.com/bankdata/styra-controller/api/config/v2alpha2"
+	"github.com/bankdata/styra-controller/internal/giturl"
+)
+
+// Resolve returns the git credentials to use for the repository with the
+// given URL. Repository URLs and prefixes are compared in their normalized
+// form, see giturl.Normalize, and the credentials with the longest matching
+// RepoPrefix are preferred, so credentials for github.com/org/team take
+// precedence over credentials for github.com/org regardless of the order in
+// the configuration. Credentials which the namespace is not allowed to use
+// are skipped, falling back to the credentials with the next longest matching
+// RepoPrefix. Credentials with the same prefix are tried in the order of the
+// configuration. Cluster-scoped resources, which have an empty namespace, are
+// not restricted by the namespace allowlists.
+func Resolve(
+	credentials []*configv2alpha2.GitCredentials,
+	repoURL string,
+	namespace string,
+) (*configv2alpha2.GitCredentials, error) {
+	var matches []*configv2alpha2.GitCredentials
+	for _, cred := range credentials {
+		if cred != nil && giturl.HasPrefix(repoURL, cred.RepoPrefix) {
+			matches = append(matches, cred)
+		}
+	}
+
+	if len(matches) == 0 {
+		return nil, errors.Errorf("no git credentials match repository %s", repoURL)
+	}
+
+	slices.SortStableFunc(matches, func(a, b *configv2alpha2.GitCredentials) int {
+		return cmp.Compare(len(giturl.Normalize(b.RepoPrefix)), len(giturl.Normalize(a.RepoPrefix)))
+	})
+
+	for _, cred := range matches {
+		if namespace == "" || namespaceAllowed(cred.Namespaces, namespace) {
+			return cred, nil
+		}
+	}
+
+	return nil, errors.Errorf(
+		"none of the git credentials matching repository %s are allowed in namespace %s",
+		repoURL, namespace,
+	)
+}
+
+// namespaceAllowed reports whether the namespace matches one of the glob
+// patterns. All namespaces are allowed when there are no patterns.
+func namespaceAllowed(patterns []string, namespace string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, pattern := range patterns {
+		if matched, _ := filepath.Match(pattern, namespace); matched {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,61 @@
+/*
+Copyright (C) 2025 Bankdata (bankdata@bankdata.dk)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gitcredentials_test
+
+import (
+	ginkgo "github.com/onsi/ginkgo/v2"
+	gomega "github.com/onsi/gomega"
+
+	configv2alpha2 "github.com/bankdata/styra-controller/api/config/v2alpha2"
+	"github.com/bankdata/styra-controller/internal/gitcredentials"
+)
+
+var _ = ginkgo.DescribeTable("Resolve",
+	func(repoURL, namespace, expectedID string) {
+		credentials := []*configv2alpha2.GitCredentials{
+			{ID: "github", RepoPrefix: "https://github.com"},
+			{ID: "org", RepoPrefix: "https://github.com/org"},
+			{ID: "team", RepoPrefix: "git@github.com:org/team", Namespaces: []string{"team-*"}},
+			{ID: "team-shared", RepoPrefix: "https://github.com/org/team", Namespaces: []string{"shared"}},
+			{ID: "gitlab", RepoPrefix: "https://gitlab.com/org", Namespaces: []string{"gitlab"}},
+		}
+
+		cred, err := gitcredentials.Resolve(credentials, repoURL, namespace)
+		if expectedID == "" {
+			gomega.Ω(err).To(gomega.HaveOccurred())
+			return
+		}
+		gomega.Ω(err).NotTo(gomega.HaveOccurred())
+		gomega.Ω(cred.ID).To(gomega.Equal(expectedID))
+	},
+	ginkgo.Entry("host prefix", "https://github.com/other/repo.git", "default", "github"),
+	ginkgo.Entry("longest prefix wins over config order", "https://github.com/org/repo.git", "default", "org"),
+	ginkgo.Entry("longest prefix in other form", "git@github.com:org/team/repo.git", "team-a", "team"),
+	ginkgo.Entry("first allowed credentials with same prefix",
+		"https://github.com/org/team/repo", "shared", "team-shared"),
+	ginkgo.Entry("falls back when most specific credentials are not allowed",
+		"https://github.com/org/team/repo", "default", "org"),
+	ginkgo.Entry("falls back past several disallowed credentials",
+		"git@github.com:org/team/repo.git", "other", "org"),
+	ginkgo.Entry("no matching credentials allowed", "https://gitlab.com/org/repo", "default", ""),
+	ginkgo.Entry("cluster-scoped", "https://github.com/org/team/repo", "", "team"),
+	ginkgo.Entry("org is not a prefix of organization",
+		"https://github.com/organization/repo.git", "default", "github"),
+	ginkgo.Entry("org is not a prefix of org-other", "git@github.com:org-other/repo.git", "default", "github"),
+	ginkgo.Entry("prefix is not a substring match", "https://example.com/github.com/org/repo", "default", ""),
+	ginkgo.Entry("no match", "https://bitbucket.org/org/repo", "default", ""),
+)
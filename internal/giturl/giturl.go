@@ -0,0 +1,163 @@
+/*
+Copyright (C) 2025 Bankdata (bankdata@bankdata.dk)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package giturl contains helpers for working with the URLs of git
+// repositories. Besides http(s) URLs, repositories can be referenced by
+// ssh:// URLs and scp-like URLs such as git@github.com:org/repo.git.
+package giturl
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	// scpRegexp matches scp-like URLs, e.g. git@github.com:org/repo.git.
+	scpRegexp = regexp.MustCompile(`^(?:([^@/:]+)@)?([^@/:]+):([^/].*)$`)
+
+	// commitRegexp matches full SHA-1 and SHA-256 git commit hashes.
+	commitRegexp = regexp.MustCompile(`^[0-9a-f]{40}([0-9a-f]{24})?$`)
+)
+
+// URL is a parsed git repository URL.
+type URL struct {
+	// Scheme is http, https or ssh. scp-like URLs have the ssh scheme.
+	Scheme string
+
+	// User is the user in the URL, e.g. git.
+	User string
+
+	// Host is the host of the URL, including the port if it is set.
+	Host string
+
+	// Path is the path of the repository on the host, without a leading
+	// slash. The path of scp-like URLs is relative to the home directory of
+	// the user, so they can not be written as ssh:// URLs and are passed on to
+	// OCP as they are.
+	Path string
+}
+
+// Parse parses a git repository URL in the http(s), ssh or scp-like form.
+func Parse(rawURL string) (*URL, error) {
+	if !strings.Contains(rawURL, "://") {
+		m := scpRegexp.FindStringSubmatch(rawURL)
+		if m == nil {
+			return nil, errors.Errorf("invalid git URL %q", rawURL)
+		}
+		return &URL{Scheme: "ssh", User: m[1], Host: m[2], Path: m[3]}, nil
+	}
+
+	parsedURL, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid git URL %q", rawURL)
+	}
+	switch parsedURL.Scheme {
+	case "http", "https", "ssh":
+	default:
+		return nil, errors.Errorf("unsupported scheme in git URL %q", rawURL)
+	}
+	if parsedURL.Host == "" {
+		return nil, errors.Errorf("missing host in git URL %q", rawURL)
+	}
+
+	u := &URL{
+		Scheme: parsedURL.Scheme,
+		Host:   parsedURL.Host,
+		Path:   strings.TrimPrefix(parsedURL.Path, "/"),
+	}
+	if parsedURL.User != nil {
+		u.User = parsedURL.User.Username()
+	}
+	return u, nil
+}
+
+// IsSSH reports whether the URL uses ssh.
+func (u *URL) IsSSH() bool {
+	return u.Scheme == "ssh"
+}
+
+// Valid reports whether rawURL is a valid git repository URL.
+func Valid(rawURL string) bool {
+	u, err := Parse(rawURL)
+	if err != nil {
+		return false
+	}
+
+	// Reject URLs with brackets in the path (invalid for repository URLs)
+	if strings.ContainsAny(u.Path, "[]") {
+		return false
+	}
+
+	return !u.IsSSH() || u.Path != ""
+}
+
+// ValidCommit reports whether commit is a full SHA-1 or SHA-256 git commit
+// hash. Abbreviated hashes are not valid, as they are ambiguous.
+func ValidCommit(commit string) bool {
+	return commitRegexp.MatchString(commit)
+}
+
+// IsSSH reports whether rawURL is a git repository URL using ssh.
+func IsSSH(rawURL string) bool {
+	u, err := Parse(rawURL)
+	return err == nil && u.IsSSH()
+}
+
+// Normalize returns the host and path of a git repository URL, e.g.
+// github.com/org/repo, which is the same for the http(s), ssh and scp-like
+// forms of the URL. The scheme, user, port and .git suffix are removed. It
+// also normalizes URLs used as prefixes, such as https://github.com/org.
+func Normalize(rawURL string) string {
+	s := strings.TrimSpace(rawURL)
+
+	var host, rest string
+	if i := strings.Index(s, "://"); i >= 0 {
+		s = s[i+len("://"):]
+		host, rest, _ = strings.Cut(s, "/")
+		if at := strings.LastIndex(host, "@"); at >= 0 {
+			host = host[at+1:]
+		}
+		if colon := strings.Index(host, ":"); colon >= 0 {
+			host = host[:colon]
+		}
+	} else if m := scpRegexp.FindStringSubmatch(s); m != nil {
+		host, rest = m[2], m[3]
+	} else {
+		host, rest, _ = strings.Cut(s, "/")
+	}
+
+	normalized := strings.ToLower(host)
+	if rest != "" {
+		normalized += "/" + strings.TrimPrefix(rest, "/")
+	}
+	normalized = strings.TrimSuffix(normalized, "/")
+	return strings.TrimSuffix(normalized, ".git")
+}
+
+// HasPrefix reports whether the git repository URL starts with the given
+// prefix, regardless of the form of the URL and prefix. The prefix must end at
+// a path segment boundary, so github.com/org does not match
+// github.com/organization/repo.
+func HasPrefix(rawURL, prefix string) bool {
+	normalizedURL, normalizedPrefix := Normalize(rawURL), Normalize(prefix)
+	if normalizedPrefix == "" {
+		return false
+	}
+	return normalizedURL == normalizedPrefix || strings.HasPrefix(normalizedURL, normalizedPrefix+"/")
+}
@@ -0,0 +1,106 @@
+/*
+Copyright (C) 2025 Bankdata (bankdata@bankdata.dk)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package giturl_test
+
+import (
+	ginkgo "github.com/onsi/ginkgo/v2"
+	gomega "github.com/onsi/gomega"
+
+	"github.com/bankdata/styra-controller/internal/giturl"
+)
+
+var _ = ginkgo.DescribeTable("Parse",
+	func(rawURL string, expected *giturl.URL) {
+		u, err := giturl.Parse(rawURL)
+		if expected == nil {
+			gomega.Ω(err).To(gomega.HaveOccurred())
+			return
+		}
+		gomega.Ω(err).NotTo(gomega.HaveOccurred())
+		gomega.Ω(u).To(gomega.Equal(expected))
+	},
+	ginkgo.Entry("https", "https://github.com/org/repo.git",
+		&giturl.URL{Scheme: "https", Host: "github.com", Path: "org/repo.git"}),
+	ginkgo.Entry("ssh", "ssh://git@github.com:22/org/repo.git",
+		&giturl.URL{Scheme: "ssh", User: "git", Host: "github.com:22", Path: "org/repo.git"}),
+	ginkgo.Entry("scp-like", "git@github.com:org/repo.git",
+		&giturl.URL{Scheme: "ssh", User: "git", Host: "github.com", Path: "org/repo.git"}),
+	ginkgo.Entry("scp-like without user", "github.com:org/repo.git",
+		&giturl.URL{Scheme: "ssh", Host: "github.com", Path: "org/repo.git"}),
+	ginkgo.Entry("scp-like relative to home", "git@example.com:repo.git",
+		&giturl.URL{Scheme: "ssh", User: "git", Host: "example.com", Path: "repo.git"}),
+	ginkgo.Entry("scp-like relative to home of user", "git@example.com:~user/repo.git",
+		&giturl.URL{Scheme: "ssh", User: "git", Host: "example.com", Path: "~user/repo.git"}),
+	ginkgo.Entry("unsupported scheme", "ftp://github.com/org/repo.git", nil),
+	ginkgo.Entry("missing host", "https:///org/repo.git", nil),
+	ginkgo.Entry("no scheme", "github.com/org/repo.git", nil),
+	ginkgo.Entry("word", "google", nil),
+)
+
+var _ = ginkgo.DescribeTable("Valid",
+	func(rawURL string, expected bool) {
+		gomega.Ω(giturl.Valid(rawURL)).To(gomega.Equal(expected))
+	},
+	ginkgo.Entry("https", "https://github.com/org/repo.git", true),
+	ginkgo.Entry("ssh", "ssh://git@github.com/org/repo.git", true),
+	ginkgo.Entry("scp-like", "git@github.com:org/repo.git", true),
+	ginkgo.Entry("brackets", "https://github.com/[org]/repo.git", false),
+	ginkgo.Entry("brackets in scp-like", "git@github.com:[org]/repo.git", false),
+	ginkgo.Entry("ssh without path", "ssh://git@github.com", false),
+	ginkgo.Entry("no scheme", "www.google.com", false),
+)
+
+var _ = ginkgo.DescribeTable("ValidCommit",
+	func(commit string, expected bool) {
+		gomega.Ω(giturl.ValidCommit(commit)).To(gomega.Equal(expected))
+	},
+	ginkgo.Entry("sha1", "f37cc9d87251921cbe49349235d9b5305c833769", true),
+	ginkgo.Entry("sha256", "f37cc9d87251921cbe49349235d9b5305c833769f37cc9d87251921cbe493492", true),
+	ginkgo.Entry("short", "f37cc9d", false),
+	ginkgo.Entry("upper case", "F37CC9D87251921CBE49349235D9B5305C833769", false),
+	ginkgo.Entry("non-hex", "g37cc9d87251921cbe49349235d9b5305c833769", false),
+	ginkgo.Entry("reference", "main", false),
+)
+
+var _ = ginkgo.DescribeTable("Normalize",
+	func(rawURL, expected string) {
+		gomega.Ω(giturl.Normalize(rawURL)).To(gomega.Equal(expected))
+	},
+	ginkgo.Entry("https", "https://github.com/org/repo.git", "github.com/org/repo"),
+	ginkgo.Entry("https with user and port", "https://user@GitHub.com:443/org/repo", "github.com/org/repo"),
+	ginkgo.Entry("ssh", "ssh://git@github.com:22/org/repo.git", "github.com/org/repo"),
+	ginkgo.Entry("scp-like", "git@github.com:org/repo.git", "github.com/org/repo"),
+	ginkgo.Entry("prefix", "https://github.com/org/", "github.com/org"),
+)
+
+var _ = ginkgo.DescribeTable("HasPrefix",
+	func(rawURL, prefix string, expected bool) {
+		gomega.Ω(giturl.HasPrefix(rawURL, prefix)).To(gomega.Equal(expected))
+	},
+	ginkgo.Entry("same form", "https://github.com/org/repo.git", "https://github.com/org", true),
+	ginkgo.Entry("scp-like url", "git@github.com:org/repo.git", "https://github.com/org", true),
+	ginkgo.Entry("ssh prefix", "https://github.com/org/repo.git", "git@github.com:org", true),
+	ginkgo.Entry("host prefix", "git@github.com:org/repo.git", "https://github.com", true),
+	ginkgo.Entry("whole URL", "https://github.com/org/repo.git", "git@github.com:org/repo.git", true),
+	ginkgo.Entry("other org", "git@github.com:other/repo.git", "https://github.com/org", false),
+	ginkgo.Entry("org is not a prefix of organization", "https://github.com/organization/repo",
+		"https://github.com/org", false),
+	ginkgo.Entry("org is not a prefix of org-other", "git@github.com:org-other/repo.git",
+		"https://github.com/org", false),
+	ginkgo.Entry("partial host", "git@github.com:org/repo.git", "https://github", false),
+	ginkgo.Entry("empty prefix", "https://github.com/org/repo.git", "", false),
+)
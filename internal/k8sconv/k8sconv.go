@@ -19,12 +19,15 @@ limitations under the License.
 package k8sconv
 
 import (
+	"encoding/json"
+
 	"github.com/go-logr/logr"
 	"github.com/pkg/errors"
 	"gopkg.in/yaml.v2"
 	corev1 "k8s.io/api/core/v1"
 
 	configv2alpha2 "github.com/bankdata/styra-controller/api/config/v2alpha2"
+	"github.com/bankdata/styra-controller/api/styra/v1beta1"
 	"github.com/bankdata/styra-controller/pkg/ocp"
 )
 
@@ -37,11 +40,28 @@ type credentials struct {
 }
 
 type authz struct {
-	Service  string `yaml:"service"`
-	Resource string `yaml:"resource"`
-	Persist  bool   `yaml:"persist,omitempty"`
+	Service  string         `yaml:"service,omitempty"`
+	Resource string         `yaml:"resource"`
+	Persist  bool           `yaml:"persist,omitempty"`
+	Polling  *bundlePolling `yaml:"polling,omitempty"`
+}
+
+type bundlePolling struct {
+	MinDelaySeconds           int `yaml:"min_delay_seconds,omitempty"`
+	MaxDelaySeconds           int `yaml:"max_delay_seconds,omitempty"`
+	LongPollingTimeoutSeconds int `yaml:"long_polling_timeout_seconds,omitempty"`
 }
 
+const (
+	// defaultDeltaBundleMinDelaySeconds is the minimum bundle polling delay
+	// used for delta bundles when none is configured.
+	defaultDeltaBundleMinDelaySeconds = 10
+
+	// defaultDeltaBundleMaxDelaySeconds is the maximum bundle polling delay
+	// used for delta bundles when none is configured.
+	defaultDeltaBundleMaxDelaySeconds = 20
+)
+
 type bundle struct {
 	Authz authz `yaml:"authz"`
 }
@@ -82,6 +102,7 @@ type DecisionLogs struct {
 	ServiceName    string                `json:"service,omitempty" yaml:"service,omitempty"`
 	ResourcePath   string                `json:"resource_path,omitempty" yaml:"resource_path,omitempty"`
 	Reporting      *DecisionLogReporting `json:"reporting,omitempty" yaml:"reporting,omitempty"`
+	MaskDecision   string                `json:"mask_decision,omitempty" yaml:"mask_decision,omitempty"`
 }
 
 // DecisionLogReporting contains configuration for decision log reporting
@@ -137,7 +158,9 @@ type HTTPMetricsConfig struct {
 
 // OPAConfToK8sOPAConfigMapforOCP creates a ConfigMap for the OPA.
 // It configures OPA to fetch bundle from MinIO.
-// OPAConfToK8sOPAConfigMapforOCP merges the information given as input into a ConfigMap for OPA
+// OPAConfToK8sOPAConfigMapforOCP merges the information given as input into a ConfigMap for OPA.
+// The discovery overrides in opaconf take precedence over the controller
+// defaults, and customConfig takes precedence over both.
 func OPAConfToK8sOPAConfigMapforOCP(
 	opaconf ocp.OPAConfig,
 	opaDefaultConfig configv2alpha2.OPAConfig,
@@ -156,7 +179,6 @@ func OPAConfToK8sOPAConfigMapforOCP(
 	ocpOPAConfigMap := OcpOPAConfigMap{
 		Bundles: bundle{
 			Authz: authz{
-				Service:  opaconf.BundleService.Name,
 				Resource: opaconf.BundleResource,
 			},
 		},
@@ -168,6 +190,7 @@ func OPAConfToK8sOPAConfigMapforOCP(
 		DecisionLogs: DecisionLogs{
 			ServiceName:  opaconf.LogService.Name,
 			ResourcePath: "/logs",
+			MaskDecision: opaconf.DecisionLogMaskDecision,
 			Reporting: &DecisionLogReporting{
 				MaxDelaySeconds:      opaconf.DecisionLogReporting.MaxDelaySeconds,
 				MinDelaySeconds:      opaconf.DecisionLogReporting.MinDelaySeconds,
@@ -176,6 +199,11 @@ func OPAConfToK8sOPAConfigMapforOCP(
 		},
 	}
 
+	// Bundles read from file:// resources are not downloaded from a service.
+	if opaconf.BundleService != nil {
+		ocpOPAConfigMap.Bundles.Authz.Service = opaconf.BundleService.Name
+	}
+
 	if opaDefaultConfig.Metrics.Prometheus.HTTP.Buckets != nil {
 		ocpOPAConfigMap.Server = Serverconfig{
 			Metrics: Metricsconfig{
@@ -196,6 +224,10 @@ func OPAConfToK8sOPAConfigMapforOCP(
 		ocpOPAConfigMap.PersistenceDirectory = opaDefaultConfig.PersistBundleDirectory
 	}
 
+	if opaconf.EnableDeltaBundles {
+		ocpOPAConfigMap.Bundles.Authz.Polling = deltaBundlePolling(opaDefaultConfig.DeltaBundlePolling)
+	}
+
 	if opaDefaultConfig.DecisionLogs.RequestContext.HTTP.Headers != nil {
 		ocpOPAConfigMap.DecisionLogs.RequestContext = requestContext{
 			HTTP: http{
@@ -209,7 +241,12 @@ func OPAConfToK8sOPAConfigMapforOCP(
 		return corev1.ConfigMap{}, err
 	}
 
-	merged := mergeMaps(opaConfigMapMapStringInterface, customConfig)
+	overrides, err := discoveryOverridesToMap(opaconf.DiscoveryOverrides)
+	if err != nil {
+		return corev1.ConfigMap{}, err
+	}
+
+	merged := mergeMaps(mergeMaps(opaConfigMapMapStringInterface, overrides), customConfig)
 
 	res, err := yaml.Marshal(&merged)
 	if err != nil {
@@ -224,6 +261,57 @@ func OPAConfToK8sOPAConfigMapforOCP(
 	return cm, nil
 }
 
+// deltaBundlePolling returns the bundle polling configuration used for delta
+// bundles. Delays which are not configured fall back to the defaults.
+func deltaBundlePolling(config *configv2alpha2.BundlePolling) *bundlePolling {
+	polling := &bundlePolling{
+		MinDelaySeconds: defaultDeltaBundleMinDelaySeconds,
+		MaxDelaySeconds: defaultDeltaBundleMaxDelaySeconds,
+	}
+	if config == nil {
+		return polling
+	}
+	if config.MinDelaySeconds != 0 {
+		polling.MinDelaySeconds = config.MinDelaySeconds
+	}
+	if config.MaxDelaySeconds != 0 {
+		polling.MaxDelaySeconds = config.MaxDelaySeconds
+	}
+	polling.LongPollingTimeoutSeconds = config.LongPollingTimeoutSeconds
+	return polling
+}
+
+// discoveryOverridesToMap converts the overrides to the status and
+// distributed_tracing sections of the OPA config. Sections which are not set
+// are left out so they do not replace the defaults.
+func discoveryOverridesToMap(overrides *v1beta1.DiscoveryOverrides) (map[string]interface{}, error) {
+	if overrides == nil {
+		return nil, nil
+	}
+
+	res := map[string]interface{}{}
+
+	if overrides.Status != nil {
+		res["status"] = map[string]interface{}{
+			"prometheus": overrides.Status.Prometheus,
+		}
+	}
+
+	if overrides.DistributedTracing != nil {
+		bs, err := json.Marshal(overrides.DistributedTracing)
+		if err != nil {
+			return nil, errors.Wrap(err, "Could not marshal distributed tracing overrides")
+		}
+		var tracing map[string]interface{}
+		if err := json.Unmarshal(bs, &tracing); err != nil {
+			return nil, errors.Wrap(err, "Could not unmarshal distributed tracing overrides")
+		}
+		res["distributed_tracing"] = tracing
+	}
+
+	return res, nil
+}
+
 func opaConfigMapToMap(cm interface{}) (map[string]interface{}, error) {
 	res, err := yaml.Marshal(&cm)
 	if err != nil {
@@ -24,6 +24,7 @@ import (
 	gomega "github.com/onsi/gomega"
 
 	configv2alpha2 "github.com/bankdata/styra-controller/api/config/v2alpha2"
+	"github.com/bankdata/styra-controller/api/styra/v1beta1"
 	"github.com/bankdata/styra-controller/internal/k8sconv"
 	"github.com/bankdata/styra-controller/pkg/ocp"
 	"gopkg.in/yaml.v2"
@@ -258,6 +259,190 @@ decision_logs:
 distributed_tracing:
   type: grpc
   address: localhost:1234
+`,
+		}),
+
+		ginkgo.Entry("decision log mask", test{
+			opaconf: ocp.OPAConfig{
+				LogService: &ocp.OPAServiceConfig{
+					Name: "logs",
+					URL:  "https://log-service/ocp",
+					Credentials: &ocp.ServiceCredentials{
+						Bearer: &ocp.Bearer{
+							TokenPath: "/etc/opa/auth/token",
+						},
+					},
+				},
+				BundleResource: "bundles/system/bundle.tar.gz",
+				BundleService: &ocp.OPAServiceConfig{
+					Name: "s3",
+					URL:  "https://minio/ocp",
+					Credentials: &ocp.ServiceCredentials{
+						S3: &ocp.S3Signing{
+							S3EnvironmentCredentials: map[string]ocp.EmptyStruct{},
+						},
+					},
+				},
+				DecisionLogMaskDecision: "/system/log/mask",
+			},
+			expectedCMContent: `services:
+- name: s3
+  url: https://minio/ocp
+  credentials:
+    s3_signing:
+      environment_credentials: {}
+- name: logs
+  url: https://log-service/ocp
+  credentials:
+    bearer:
+      token_path: /etc/opa/auth/token
+bundles:
+  authz:
+    resource: bundles/system/bundle.tar.gz
+    service: s3
+decision_logs:
+  reporting: {}
+  service: logs
+  resource_path: /logs
+  mask_decision: /system/log/mask
+`,
+		}),
+
+		ginkgo.Entry("discovery overrides", test{
+			opaDefaultConfig: configv2alpha2.OPAConfig{
+				Metrics: configv2alpha2.MetricsConfig{
+					Prometheus: configv2alpha2.PrometheusMetricsConfig{
+						HTTP: configv2alpha2.HTTPMetricsConfig{
+							Buckets: []float64{0.1, 1},
+						},
+					},
+				},
+			},
+			opaconf: ocp.OPAConfig{
+				LogService: &ocp.OPAServiceConfig{
+					Name: "logs",
+					URL:  "https://log-service/ocp",
+					Credentials: &ocp.ServiceCredentials{
+						Bearer: &ocp.Bearer{
+							TokenPath: "/etc/opa/auth/token",
+						},
+					},
+				},
+				BundleResource: "bundles/system/bundle.tar.gz",
+				BundleService: &ocp.OPAServiceConfig{
+					Name: "s3",
+					URL:  "https://minio/ocp",
+					Credentials: &ocp.ServiceCredentials{
+						S3: &ocp.S3Signing{
+							S3EnvironmentCredentials: map[string]ocp.EmptyStruct{},
+						},
+					},
+				},
+				DiscoveryOverrides: &v1beta1.DiscoveryOverrides{
+					Status: &v1beta1.OPAConfigStatus{
+						Prometheus: false,
+					},
+					DistributedTracing: &v1beta1.OPAConfigDistributedTracing{
+						Type:             "grpc",
+						Address:          "localhost:4317",
+						ServiceName:      "opa",
+						SamplePercentage: 50,
+					},
+				},
+			},
+			customConfig: map[string]interface{}{
+				"distributed_tracing": map[string]interface{}{
+					"address": "localhost:1234", //test that custom config overrides discovery overrides
+				},
+			},
+			expectedCMContent: `services:
+- name: s3
+  url: https://minio/ocp
+  credentials:
+    s3_signing:
+      environment_credentials: {}
+- name: logs
+  url: https://log-service/ocp
+  credentials:
+    bearer:
+      token_path: /etc/opa/auth/token
+bundles:
+  authz:
+    resource: bundles/system/bundle.tar.gz
+    service: s3
+decision_logs:
+  reporting: {}
+  service: logs
+  resource_path: /logs
+server:
+  metrics:
+    prom:
+      http_request_duration_seconds:
+        buckets:
+        - 0.1
+        - 1
+status:
+  prometheus: false
+distributed_tracing:
+  type: grpc
+  address: localhost:1234
+  service_name: opa
+  sample_percentage: 50
+`,
+		}),
+
+		ginkgo.Entry("delta bundles", test{
+			opaDefaultConfig: configv2alpha2.OPAConfig{
+				DeltaBundlePolling: &configv2alpha2.BundlePolling{
+					MaxDelaySeconds:           30,
+					LongPollingTimeoutSeconds: 60,
+				},
+			},
+			opaconf: ocp.OPAConfig{
+				LogService: &ocp.OPAServiceConfig{
+					Name: "logs",
+					URL:  "https://log-service/ocp",
+					Credentials: &ocp.ServiceCredentials{
+						Bearer: &ocp.Bearer{
+							TokenPath: "/etc/opa/auth/token",
+						},
+					},
+				},
+				BundleResource: "bundles/system/bundle.tar.gz",
+				BundleService: &ocp.OPAServiceConfig{
+					Name: "s3",
+					URL:  "https://minio/ocp",
+					Credentials: &ocp.ServiceCredentials{
+						S3: &ocp.S3Signing{
+							S3EnvironmentCredentials: map[string]ocp.EmptyStruct{},
+						},
+					},
+				},
+				EnableDeltaBundles: true,
+			},
+			expectedCMContent: `services:
+- name: s3
+  url: https://minio/ocp
+  credentials:
+    s3_signing:
+      environment_credentials: {}
+- name: logs
+  url: https://log-service/ocp
+  credentials:
+    bearer:
+      token_path: /etc/opa/auth/token
+bundles:
+  authz:
+    resource: bundles/system/bundle.tar.gz
+    service: s3
+    polling:
+      min_delay_seconds: 10
+      max_delay_seconds: 30
+      long_polling_timeout_seconds: 60
+decision_logs:
+  reporting: {}
+  service: logs
+  resource_path: /logs
 `,
 		}),
 	)
@@ -32,6 +32,14 @@ const (
 	LabelValueControlPlaneOCP = "opa-control-plane"
 )
 
+// Constants for labels configured on bundles in OPA Control Plane.
+const (
+	LabelSystemNamespace        = "styra-controller/system-namespace"
+	LabelSystemName             = "styra-controller/system-name"
+	LabelDeletionProtection     = "styra-controller/deletion-protection"
+	LabelValueDeletionProtected = "true"
+)
+
 // ControllerClassLabelSelector creates a metav1.LabelSelector which selects
 // objects that has the "styra-controller/class" label with the value `class`.
 func ControllerClassLabelSelector(class string) metav1.LabelSelector {
@@ -87,3 +95,24 @@ func ControllerClassMatches(o client.Object, class string) bool {
 	}
 	return labels[labelControllerClass] == class
 }
+
+// OCPBundleLabels returns the labels the controller sets on the bundle of a
+// System in OPA Control Plane. The labels identify the controller class and
+// the System which the bundle belongs to.
+func OCPBundleLabels(class, namespace, name string) map[string]string {
+	bundleLabels := map[string]string{
+		labelManagedBy:       labelValueManagedBy,
+		LabelSystemNamespace: namespace,
+		LabelSystemName:      name,
+	}
+	if class != "" {
+		bundleLabels[labelControllerClass] = class
+	}
+	return bundleLabels
+}
+
+// OCPBundleManaged checks if a bundle in OPA Control Plane with the given
+// labels is managed by the controller with the class `class`.
+func OCPBundleManaged(bundleLabels map[string]string, class string) bool {
+	return bundleLabels[labelManagedBy] == labelValueManagedBy && bundleLabels[labelControllerClass] == class
+}
@@ -90,3 +90,30 @@ var _ = ginkgo.DescribeTable("ControllerClassMatches",
 		true,
 	),
 )
+
+var _ = ginkgo.Describe("OCPBundleLabels", func() {
+	ginkgo.It("should not set the class label for the default class", func() {
+		gomega.Ω(labels.OCPBundleLabels("", "default", "system")).To(gomega.Equal(map[string]string{
+			"app.kubernetes.io/managed-by":      "styra-controller",
+			"styra-controller/system-namespace": "default",
+			"styra-controller/system-name":      "system",
+		}))
+	})
+
+	ginkgo.It("should set the class label", func() {
+		gomega.Ω(labels.OCPBundleLabels("test", "default", "system")).
+			To(gomega.HaveKeyWithValue("styra-controller/class", "test"))
+	})
+})
+
+var _ = ginkgo.DescribeTable("OCPBundleManaged",
+	func(bundleLabels map[string]string, class string, expected bool) {
+		gomega.Ω(labels.OCPBundleManaged(bundleLabels, class)).To(gomega.Equal(expected))
+	},
+	ginkgo.Entry("should return false without labels", nil, "", false),
+	ginkgo.Entry("should return true for the default class", labels.OCPBundleLabels("", "ns", "name"), "", true),
+	ginkgo.Entry("should return true for a matching class", labels.OCPBundleLabels("test", "ns", "name"), "test", true),
+	ginkgo.Entry("should return false for another class", labels.OCPBundleLabels("test", "ns", "name"), "", false),
+	ginkgo.Entry("should return false if not managed by the controller",
+		map[string]string{"styra-controller/class": "test"}, "test", false),
+)
@@ -19,22 +19,37 @@ package v1alpha1
 
 import (
 	"context"
+	"regexp"
+	"sort"
 
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation/field"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 
+	configv2alpha2 "github.com/bankdata/styra-controller/api/config/v2alpha2"
 	styrav1alpha1 "github.com/bankdata/styra-controller/api/styra/v1alpha1"
+	"github.com/bankdata/styra-controller/internal/gitcredentials"
+	"github.com/bankdata/styra-controller/internal/giturl"
 )
 
+// sourceIDRegexp matches the ids OCP accepts for sources.
+var sourceIDRegexp = regexp.MustCompile(`^[a-zA-Z0-9]([a-zA-Z0-9._-]*[a-zA-Z0-9])?$`)
+
 // nolint:all
 // log is for logging in this package.
 var librarylog = logf.Log.WithName("library-resource")
 
 // SetupLibraryWebhookWithManager registers the webhook for Library in the manager.
-func SetupLibraryWebhookWithManager(mgr ctrl.Manager) error {
+func SetupLibraryWebhookWithManager(mgr ctrl.Manager, config *configv2alpha2.ProjectConfig) error {
 	return ctrl.NewWebhookManagedBy(mgr, &styrav1alpha1.Library{}).
-		WithValidator(&LibraryCustomValidator{}).
+		WithValidator(&LibraryCustomValidator{
+			Client: mgr.GetClient(),
+			Config: config,
+		}).
 		WithDefaulter(&LibraryCustomDefaulter{}).
 		Complete()
 }
@@ -84,7 +99,13 @@ func (d *LibraryCustomDefaulter) Default(ctx context.Context, library *styrav1al
 // NOTE: The +kubebuilder:object:generate=false marker prevents controller-gen from generating DeepCopy methods,
 // as this struct is used only for temporary operations and does not need to be deeply copied.
 type LibraryCustomValidator struct {
-	// TODO(user): Add more fields as needed for validation
+	// Client is used to look up other Libraries when validating that
+	// spec.name is unique.
+	Client client.Reader
+
+	// Config is the controller configuration used to validate that the
+	// library repository is covered by the configured git credentials.
+	Config *configv2alpha2.ProjectConfig
 }
 
 var _ admission.Validator[*styrav1alpha1.Library] = &LibraryCustomValidator{}
@@ -94,9 +115,7 @@ var _ admission.Validator[*styrav1alpha1.Library] = &LibraryCustomValidator{}
 func (v *LibraryCustomValidator) ValidateCreate(ctx context.Context, library *styrav1alpha1.Library) (admission.Warnings, error) {
 	librarylog.Info("Validation for Library upon creation", "name", library.GetName())
 
-	// TODO(user): fill in your validation logic upon object creation.
-
-	return nil, nil
+	return v.validateLibrary(ctx, library)
 }
 
 // nolint:all
@@ -104,9 +123,7 @@ func (v *LibraryCustomValidator) ValidateCreate(ctx context.Context, library *st
 func (v *LibraryCustomValidator) ValidateUpdate(ctx context.Context, oldObj, library *styrav1alpha1.Library) (admission.Warnings, error) {
 	librarylog.Info("Validation for Library upon update", "name", library.GetName())
 
-	// TODO(user): fill in your validation logic upon object update.
-
-	return nil, nil
+	return v.validateLibrary(ctx, library)
 }
 
 // nolint:all
@@ -118,3 +135,167 @@ func (v *LibraryCustomValidator) ValidateDelete(ctx context.Context, library *st
 
 	return nil, nil
 }
+
+func (v *LibraryCustomValidator) validateLibrary(
+	ctx context.Context,
+	l *styrav1alpha1.Library,
+) (admission.Warnings, error) {
+	var errs field.ErrorList
+
+	errs = append(errs, v.validateLibrarySpec(ctx, l, field.NewPath("spec"))...)
+
+	if len(errs) > 0 {
+		return nil, apierrors.NewInvalid(
+			schema.GroupKind{Group: styrav1alpha1.GroupVersion.Group, Kind: "Library"},
+			l.Name,
+			errs,
+		)
+	}
+
+	return v.gitCredentialsWarnings(l), nil
+}
+
+func (v *LibraryCustomValidator) validateLibrarySpec(
+	ctx context.Context,
+	l *styrav1alpha1.Library,
+	path *field.Path,
+) field.ErrorList {
+	var errs field.ErrorList
+
+	errs = append(errs, validateLibraryName(&l.Spec, path.Child("name"))...)
+	errs = append(errs, v.validateLibraryNameUnique(ctx, l, path.Child("name"))...)
+	errs = append(errs, v.validateSourceControl(&l.Spec, path.Child("sourceControl"))...)
+	errs = append(errs, validateLibraryDatasources(&l.Spec, path.Child("datasources"))...)
+
+	return errs
+}
+
+func validateLibraryName(s *styrav1alpha1.LibrarySpec, path *field.Path) field.ErrorList {
+	var errs field.ErrorList
+
+	if s.Name == "" {
+		return append(errs, field.Required(path, "name is required"))
+	}
+
+	if !sourceIDRegexp.MatchString(s.Name) {
+		errs = append(errs, field.Invalid(path, s.Name,
+			"must consist of alphanumeric characters, '-', '_' or '.', "+
+				"and must start and end with an alphanumeric character"))
+	}
+
+	return errs
+}
+
+func (v *LibraryCustomValidator) validateLibraryNameUnique(
+	ctx context.Context,
+	l *styrav1alpha1.Library,
+	path *field.Path,
+) field.ErrorList {
+	var errs field.ErrorList
+
+	if v.Client == nil || l.Spec.Name == "" {
+		return errs
+	}
+
+	var libraries styrav1alpha1.LibraryList
+	if err := v.Client.List(ctx, &libraries); err != nil {
+		return append(errs, field.InternalError(path, err))
+	}
+
+	for _, other := range libraries.Items {
+		if other.Name == l.Name {
+			continue
+		}
+		if other.Spec.Name == l.Spec.Name {
+			errs = append(errs, field.Duplicate(path, l.Spec.Name))
+			break
+		}
+	}
+
+	return errs
+}
+
+func (v *LibraryCustomValidator) validateSourceControl(
+	s *styrav1alpha1.LibrarySpec,
+	path *field.Path,
+) field.ErrorList {
+	var errs field.ErrorList
+
+	if s.SourceControl == nil || s.SourceControl.LibraryOrigin == nil {
+		return append(errs, field.Required(path.Child("libraryOrigin"), "libraryOrigin is required"))
+	}
+
+	errs = append(errs, v.validateGitRepo(s.SourceControl.LibraryOrigin, path.Child("libraryOrigin"))...)
+
+	return errs
+}
+
+func (v *LibraryCustomValidator) validateGitRepo(repo *styrav1alpha1.GitRepo, path *field.Path) field.ErrorList {
+	var errs field.ErrorList
+
+	if repo.URL == "" {
+		errs = append(errs, field.Required(path.Child("url"), "url is required"))
+	} else if !giturl.Valid(repo.URL) {
+		errs = append(errs, field.Invalid(path.Child("url"), repo.URL,
+			"must be a valid http(s) or ssh git repository URL"))
+	}
+
+	if repo.Commit != "" && !giturl.ValidCommit(repo.Commit) {
+		errs = append(errs, field.Invalid(path.Child("commit"), repo.Commit, "must be a full git commit SHA"))
+	}
+
+	return errs
+}
+
+// gitCredentialsWarnings warns when none of the configured git credentials
+// can be used for the repository of the Library. Libraries are cluster-scoped,
+// so they are not restricted by the namespace allowlists of the credentials.
+// As for Systems, this is a warning rather than an error, as the Library may
+// belong to a controller class with other credentials than the controller
+// serving the webhook.
+func (v *LibraryCustomValidator) gitCredentialsWarnings(l *styrav1alpha1.Library) admission.Warnings {
+	if v.Config == nil || v.Config.OPAControlPlaneConfig == nil {
+		return nil
+	}
+	if l.Spec.SourceControl == nil || l.Spec.SourceControl.LibraryOrigin == nil ||
+		!giturl.Valid(l.Spec.SourceControl.LibraryOrigin.URL) {
+		return nil
+	}
+
+	_, err := gitcredentials.Resolve(v.Config.OPAControlPlaneConfig.GitCredentials,
+		l.Spec.SourceControl.LibraryOrigin.URL, "")
+	if err != nil {
+		return admission.Warnings{
+			field.NewPath("spec", "sourceControl", "libraryOrigin", "url").String() + ": " + err.Error(),
+		}
+	}
+
+	return nil
+}
+
+func validateLibraryDatasources(s *styrav1alpha1.LibrarySpec, path *field.Path) field.ErrorList {
+	var errs field.ErrorList
+
+	idxsByPath := map[string][]int{}
+
+	for i, ds := range s.Datasources {
+		idxsByPath[ds.Path] = append(idxsByPath[ds.Path], i)
+	}
+
+	paths := make([]string, 0, len(idxsByPath))
+	for name := range idxsByPath {
+		paths = append(paths, name)
+	}
+	sort.Strings(paths)
+
+	for _, name := range paths {
+		idxs := idxsByPath[name]
+		if len(idxs) > 1 {
+			for _, idx := range idxs {
+				errs = append(errs, field.Duplicate(path.Index(idx).Child("path"), name))
+			}
+		}
+	}
+
+	return errs
+}
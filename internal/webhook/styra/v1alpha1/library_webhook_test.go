@@ -17,71 +17,162 @@ limitations under the License.
 package v1alpha1
 
 import (
+	"context"
+	"errors"
+
 	ginkgo "github.com/onsi/ginkgo/v2"
 	gomega "github.com/onsi/gomega"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 
+	configv2alpha2 "github.com/bankdata/styra-controller/api/config/v2alpha2"
 	styrav1alpha1 "github.com/bankdata/styra-controller/api/styra/v1alpha1"
-	// TODO (user): Add any additional imports if needed
 )
 
 var _ = ginkgo.Describe("Library Webhook", func() {
 	var (
 		obj       *styrav1alpha1.Library
-		oldObj    *styrav1alpha1.Library
 		validator LibraryCustomValidator
 		defaulter LibraryCustomDefaulter
 	)
 
+	newLibrary := func(name, specName string) *styrav1alpha1.Library {
+		return &styrav1alpha1.Library{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec: styrav1alpha1.LibrarySpec{
+				Name: specName,
+				SourceControl: &styrav1alpha1.SourceControl{
+					LibraryOrigin: &styrav1alpha1.GitRepo{
+						URL:    "https://github.com/Bankdata/styra-controller.git",
+						Commit: "f37cc9d87251921cbe49349235d9b5305c833769",
+					},
+				},
+			},
+		}
+	}
+
+	expectFieldErrors := func(err error, expErrs field.ErrorList) {
+		gomega.Ω(err).To(gomega.HaveOccurred())
+		var sErr *apierrors.StatusError
+		gomega.Ω(errors.As(err, &sErr)).To(gomega.BeTrue())
+		causes := sErr.ErrStatus.Details.Causes
+		gomega.Ω(len(causes)).To(gomega.Equal(len(expErrs)))
+		for i, expErr := range expErrs {
+			gomega.Ω(string(causes[i].Type)).To(gomega.Equal(string(expErr.Type)))
+			gomega.Ω(causes[i].Field).To(gomega.Equal(expErr.Field))
+		}
+	}
+
 	ginkgo.BeforeEach(func() {
-		obj = &styrav1alpha1.Library{}
-		oldObj = &styrav1alpha1.Library{}
-		validator = LibraryCustomValidator{}
-		gomega.Expect(validator).NotTo(gomega.BeNil(), "Expected validator to be initialized")
-		defaulter = LibraryCustomDefaulter{}
-		gomega.Expect(defaulter).NotTo(gomega.BeNil(), "Expected defaulter to be initialized")
-		gomega.Expect(oldObj).NotTo(gomega.BeNil(), "Expected oldObj to be initialized")
-		gomega.Expect(obj).NotTo(gomega.BeNil(), "Expected obj to be initialized")
-		// TODO (user): Add any setup logic common to all tests
-	})
+		scheme := runtime.NewScheme()
+		gomega.Expect(styrav1alpha1.AddToScheme(scheme)).To(gomega.Succeed())
 
-	ginkgo.AfterEach(func() {
-		// TODO (user): Add any teardown logic common to all tests
+		obj = newLibrary("my-library", "mylibrary")
+		validator = LibraryCustomValidator{
+			Client: fake.NewClientBuilder().
+				WithScheme(scheme).
+				WithObjects(newLibrary("other-library", "otherlibrary")).
+				Build(),
+			Config: &configv2alpha2.ProjectConfig{
+				OPAControlPlaneConfig: &configv2alpha2.OPAControlPlaneConfig{
+					GitCredentials: []*configv2alpha2.GitCredentials{{
+						ID:         "github-credentials",
+						RepoPrefix: "https://github.com/Bankdata",
+					}},
+				},
+			},
+		}
+		defaulter = LibraryCustomDefaulter{}
 	})
 
 	ginkgo.Context("When creating Library under Defaulting Webhook", func() {
-		// TODO (user): Add logic for defaulting webhooks
-		// Example:
-		// It("Should apply defaults when a required field is empty", func() {
-		//     By("simulating a scenario where defaults should be applied")
-		//     obj.SomeFieldWithDefault = ""
-		//     By("calling the Default method to apply defaults")
-		//     defaulter.Default(ctx, obj)
-		//     By("checking that the default values are set")
-		//     Expect(obj.SomeFieldWithDefault).To(Equal("default_value"))
-		// })
+		ginkgo.It("clears the reference when a commit is set", func() {
+			obj.Spec.SourceControl.LibraryOrigin.Reference = "refs/heads/main"
+			gomega.Ω(defaulter.Default(context.Background(), obj)).To(gomega.Succeed())
+			gomega.Ω(obj.Spec.SourceControl.LibraryOrigin.Reference).To(gomega.BeEmpty())
+		})
 	})
 
 	ginkgo.Context("When creating or updating Library under Validating Webhook", func() {
-		// TODO (user): Add logic for validating webhooks
-		// Example:
-		// It("Should deny creation if a required field is missing", func() {
-		//     By("simulating an invalid creation scenario")
-		//     obj.SomeRequiredField = ""
-		//     Expect(validator.ValidateCreate(ctx, obj)).Error().To(HaveOccurred())
-		// })
-		//
-		// It("Should admit creation if all required fields are present", func() {
-		//     By("simulating an invalid creation scenario")
-		//     obj.SomeRequiredField = "valid_value"
-		//     Expect(validator.ValidateCreate(ctx, obj)).To(BeNil())
-		// })
-		//
-		// It("Should validate updates correctly", func() {
-		//     By("simulating a valid update scenario")
-		//     oldObj.SomeRequiredField = "updated_value"
-		//     obj.SomeRequiredField = "updated_value"
-		//     Expect(validator.ValidateUpdate(ctx, oldObj, obj)).To(BeNil())
-		// })
-	})
+		path := field.NewPath("spec")
+
+		ginkgo.It("admits a valid Library", func() {
+			gomega.Ω(validator.ValidateCreate(context.Background(), obj)).Error().NotTo(gomega.HaveOccurred())
+			gomega.Ω(validator.ValidateUpdate(context.Background(), obj, obj)).Error().NotTo(gomega.HaveOccurred())
+		})
+
+		ginkgo.It("requires libraryOrigin", func() {
+			obj.Spec.SourceControl = nil
+			_, err := validator.ValidateCreate(context.Background(), obj)
+			expectFieldErrors(err, field.ErrorList{
+				field.Required(path.Child("sourceControl", "libraryOrigin"), ""),
+			})
+		})
 
+		ginkgo.It("rejects invalid git URLs", func() {
+			obj.Spec.SourceControl.LibraryOrigin.URL = "github.com/Bankdata/styra-controller"
+			_, err := validator.ValidateCreate(context.Background(), obj)
+			expectFieldErrors(err, field.ErrorList{
+				field.Invalid(path.Child("sourceControl", "libraryOrigin", "url"), "", ""),
+			})
+		})
+
+		ginkgo.It("warns about repositories not covered by git credentials", func() {
+			obj.Spec.SourceControl.LibraryOrigin.URL = "https://gitlab.com/Bankdata/styra-controller.git"
+			warnings, err := validator.ValidateCreate(context.Background(), obj)
+			gomega.Ω(err).NotTo(gomega.HaveOccurred())
+			gomega.Ω(warnings).To(gomega.ConsistOf(
+				gomega.HavePrefix("spec.sourceControl.libraryOrigin.url: no git credentials match")))
+		})
+
+		ginkgo.It("does not warn about repositories covered by git credentials", func() {
+			warnings, err := validator.ValidateCreate(context.Background(), obj)
+			gomega.Ω(err).NotTo(gomega.HaveOccurred())
+			gomega.Ω(warnings).To(gomega.BeEmpty())
+		})
+
+		ginkgo.It("rejects commits that are not SHAs", func() {
+			obj.Spec.SourceControl.LibraryOrigin.Commit = "main"
+			_, err := validator.ValidateCreate(context.Background(), obj)
+			expectFieldErrors(err, field.ErrorList{
+				field.Invalid(path.Child("sourceControl", "libraryOrigin", "commit"), "", ""),
+			})
+		})
+
+		ginkgo.It("rejects duplicate datasource paths", func() {
+			obj.Spec.Datasources = []styrav1alpha1.LibraryDatasource{
+				{Path: "a"},
+				{Path: "b"},
+				{Path: "a"},
+			}
+			_, err := validator.ValidateCreate(context.Background(), obj)
+			expectFieldErrors(err, field.ErrorList{
+				field.Duplicate(path.Child("datasources").Index(0).Child("path"), "a"),
+				field.Duplicate(path.Child("datasources").Index(2).Child("path"), "a"),
+			})
+		})
+
+		ginkgo.It("rejects names which are not valid source ids", func() {
+			obj.Spec.Name = "my/library"
+			_, err := validator.ValidateCreate(context.Background(), obj)
+			expectFieldErrors(err, field.ErrorList{
+				field.Invalid(path.Child("name"), "", ""),
+			})
+		})
+
+		ginkgo.It("rejects names used by another Library", func() {
+			obj.Spec.Name = "otherlibrary"
+			_, err := validator.ValidateCreate(context.Background(), obj)
+			expectFieldErrors(err, field.ErrorList{
+				field.Duplicate(path.Child("name"), "otherlibrary"),
+			})
+
+			ginkgo.By("allowing the Library itself to keep its name on update")
+			other := newLibrary("other-library", "otherlibrary")
+			gomega.Ω(validator.ValidateUpdate(context.Background(), other, other)).Error().NotTo(gomega.HaveOccurred())
+		})
+	})
 })
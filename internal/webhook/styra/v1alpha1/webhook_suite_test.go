@@ -39,6 +39,7 @@ import (
 	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
 
+	configv2alpha2 "github.com/bankdata/styra-controller/api/config/v2alpha2"
 	styrav1alpha1 "github.com/bankdata/styra-controller/api/styra/v1alpha1"
 	// +kubebuilder:scaffold:imports
 )
@@ -63,6 +64,10 @@ func TestAPIs(t *testing.T) {
 var _ = ginkgo.BeforeSuite(func() {
 	logf.SetLogger(zap.New(zap.WriteTo(ginkgo.GinkgoWriter), zap.UseDevMode(true)))
 
+	if !ginkgo.Label("integration").MatchesLabelFilter(ginkgo.GinkgoLabelFilter()) {
+		return
+	}
+
 	ctx, cancel = context.WithCancel(context.TODO())
 
 	var err error
@@ -109,7 +114,7 @@ var _ = ginkgo.BeforeSuite(func() {
 	})
 	gomega.Expect(err).NotTo(gomega.HaveOccurred())
 
-	err = SetupLibraryWebhookWithManager(mgr)
+	err = SetupLibraryWebhookWithManager(mgr, &configv2alpha2.ProjectConfig{})
 	gomega.Expect(err).NotTo(gomega.HaveOccurred())
 
 	// +kubebuilder:scaffold:webhook
@@ -134,10 +139,19 @@ var _ = ginkgo.BeforeSuite(func() {
 })
 
 var _ = ginkgo.AfterSuite(func() {
+	if testing.Short() {
+		return
+	}
+
+	if cancel != nil {
+		cancel()
+	}
+
 	ginkgo.By("tearing down the test environment")
-	cancel()
-	err := testEnv.Stop()
-	gomega.Expect(err).NotTo(gomega.HaveOccurred())
+	if testEnv != nil {
+		err := testEnv.Stop()
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+	}
 })
 
 // getFirstFoundEnvTestBinaryDir locates the first binary in the specified path.
@@ -19,16 +19,22 @@ package v1beta1
 
 import (
 	"context"
+	pathpkg "path"
 	"sort"
+	"strings"
 
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1validation "k8s.io/apimachinery/pkg/apis/meta/v1/validation"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/util/validation/field"
 	ctrl "sigs.k8s.io/controller-runtime"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 
+	configv2alpha2 "github.com/bankdata/styra-controller/api/config/v2alpha2"
 	styrav1beta1 "github.com/bankdata/styra-controller/api/styra/v1beta1"
+	"github.com/bankdata/styra-controller/internal/gitcredentials"
+	"github.com/bankdata/styra-controller/internal/giturl"
 )
 
 // nolint:all
@@ -36,9 +42,9 @@ import (
 var systemlog = logf.Log.WithName("system-resource")
 
 // SetupSystemWebhookWithManager registers the webhook for System in the manager.
-func SetupSystemWebhookWithManager(mgr ctrl.Manager) error {
+func SetupSystemWebhookWithManager(mgr ctrl.Manager, config *configv2alpha2.ProjectConfig) error {
 	return ctrl.NewWebhookManagedBy(mgr, &styrav1beta1.System{}).
-		WithValidator(&SystemCustomValidator{}).
+		WithValidator(&SystemCustomValidator{Config: config}).
 		WithDefaulter(&SystemCustomDefaulter{}).
 		Complete()
 }
@@ -86,7 +92,9 @@ func (d *SystemCustomDefaulter) Default(ctx context.Context, system *styrav1beta
 // NOTE: The +kubebuilder:object:generate=false marker prevents controller-gen from generating DeepCopy methods,
 // as this struct is used only for temporary operations and does not need to be deeply copied.
 type SystemCustomValidator struct {
-	// TODO(user): Add more fields as needed for validation
+	// Config is the controller configuration used to warn when no configured
+	// git credentials can be used for the repository of the System.
+	Config *configv2alpha2.ProjectConfig
 }
 
 var _ admission.Validator[*styrav1beta1.System] = &SystemCustomValidator{}
@@ -96,7 +104,7 @@ var _ admission.Validator[*styrav1beta1.System] = &SystemCustomValidator{}
 func (v *SystemCustomValidator) ValidateCreate(ctx context.Context, system *styrav1beta1.System) (admission.Warnings, error) {
 	systemlog.Info("Validation for System upon creation", "name", system.GetName())
 
-	return validateSystem(system)
+	return v.validate(system)
 }
 
 // nolint:all
@@ -104,7 +112,7 @@ func (v *SystemCustomValidator) ValidateCreate(ctx context.Context, system *styr
 func (v *SystemCustomValidator) ValidateUpdate(ctx context.Context, oldObj, system *styrav1beta1.System) (admission.Warnings, error) {
 	systemlog.Info("Validation for System upon update", "name", system.GetName())
 
-	return validateSystem(system)
+	return v.validate(system)
 }
 
 // nolint:all
@@ -117,6 +125,39 @@ func (v *SystemCustomValidator) ValidateDelete(ctx context.Context, system *styr
 	return nil, nil
 }
 
+func (v *SystemCustomValidator) validate(system *styrav1beta1.System) (admission.Warnings, error) {
+	warnings, err := validateSystem(system)
+	if err != nil {
+		return warnings, err
+	}
+
+	return append(warnings, v.gitCredentialsWarnings(system)...), nil
+}
+
+// gitCredentialsWarnings warns when the System neither brings its own git
+// credentials nor can use any of the configured git credentials. This is a
+// warning rather than an error, as the System may belong to a controller
+// class with other credentials than the controller serving the webhook.
+func (v *SystemCustomValidator) gitCredentialsWarnings(system *styrav1beta1.System) admission.Warnings {
+	if v.Config == nil || v.Config.OPAControlPlaneConfig == nil {
+		return nil
+	}
+	if system.Spec.SourceControl == nil || system.Spec.SourceControl.Origin.URL == "" ||
+		system.Spec.SourceControl.Origin.CredentialsSecretName != "" {
+		return nil
+	}
+
+	_, err := gitcredentials.Resolve(v.Config.OPAControlPlaneConfig.GitCredentials,
+		system.Spec.SourceControl.Origin.URL, system.Namespace)
+	if err != nil {
+		return admission.Warnings{
+			field.NewPath("spec", "sourceControl", "origin", "url").String() + ": " + err.Error(),
+		}
+	}
+
+	return nil
+}
+
 func validateSystem(s *styrav1beta1.System) (admission.Warnings, error) {
 	var errs field.ErrorList
 
@@ -138,10 +179,104 @@ func validateSystemSpec(s *styrav1beta1.SystemSpec, path *field.Path) field.Erro
 
 	errs = append(errs, validateDecisionMappings(s, path.Child("decisionMappings"))...)
 	errs = append(errs, validateDatasources(s, path.Child("datasources"))...)
+	errs = append(errs, validateFiles(s, path)...)
+	errs = append(errs, validateRequirements(s, path.Child("requirements"))...)
+	errs = append(errs, validateSourceControl(s, path.Child("sourceControl"))...)
+	if s.LibrarySelector != nil {
+		errs = append(errs, metav1validation.ValidateLabelSelector(
+			s.LibrarySelector, metav1validation.LabelSelectorValidationOptions{}, path.Child("librarySelector"))...)
+	}
 
 	return errs
 }
 
+func validateSourceControl(s *styrav1beta1.SystemSpec, path *field.Path) field.ErrorList {
+	var errs field.ErrorList
+
+	if s.SourceControl == nil || s.SourceControl.Origin.URL == "" {
+		return errs
+	}
+
+	if !giturl.Valid(s.SourceControl.Origin.URL) {
+		errs = append(errs, field.Invalid(path.Child("origin", "url"), s.SourceControl.Origin.URL,
+			"must be a valid http(s) or ssh git repository URL"))
+	}
+
+	if commit := s.SourceControl.Origin.Commit; commit != "" && !giturl.ValidCommit(commit) {
+		errs = append(errs, field.Invalid(path.Child("origin", "commit"), commit, "must be a full git commit SHA"))
+	}
+
+	return errs
+}
+
+func validateRequirements(s *styrav1beta1.SystemSpec, path *field.Path) field.ErrorList {
+	var errs field.ErrorList
+
+	idxsBySource := map[string][]int{}
+	for i, requirement := range s.Requirements {
+		if requirement.Source == "" {
+			errs = append(errs, field.Required(path.Index(i).Child("source"), ""))
+			continue
+		}
+		if requirement.Commit != "" && !giturl.ValidCommit(requirement.Commit) {
+			errs = append(errs, field.Invalid(path.Index(i).Child("commit"), requirement.Commit,
+				"must be a full git commit SHA"))
+		}
+		idxsBySource[requirement.Source] = append(idxsBySource[requirement.Source], i)
+	}
+
+	sources := make([]string, 0, len(idxsBySource))
+	for source := range idxsBySource {
+		sources = append(sources, source)
+	}
+	sort.Strings(sources)
+
+	for _, source := range sources {
+		idxs := idxsBySource[source]
+		if len(idxs) > 1 {
+			for _, idx := range idxs {
+				errs = append(errs, field.Duplicate(path.Index(idx).Child("source"), source))
+			}
+		}
+	}
+
+	return errs
+}
+
+func validateFiles(s *styrav1beta1.SystemSpec, path *field.Path) field.ErrorList {
+	var errs field.ErrorList
+
+	names := make([]string, 0, len(s.Files))
+	for name := range s.Files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if !validFilePath(name) {
+			errs = append(errs, field.Invalid(path.Child("files").Key(name), name,
+				"must be a relative path within the source"))
+		}
+	}
+
+	for i, filesFrom := range s.FilesFrom {
+		if filesFrom.ConfigMapName == "" {
+			errs = append(errs, field.Required(path.Child("filesFrom").Index(i).Child("configMapName"), ""))
+		}
+		if filesFrom.Path != "" && !validFilePath(filesFrom.Path) {
+			errs = append(errs, field.Invalid(path.Child("filesFrom").Index(i).Child("path"), filesFrom.Path,
+				"must be a relative path within the source"))
+		}
+	}
+
+	return errs
+}
+
+func validFilePath(p string) bool {
+	cleaned := pathpkg.Clean(p)
+	return !pathpkg.IsAbs(cleaned) && cleaned != "." && cleaned != ".." && !strings.HasPrefix(cleaned, "../")
+}
+
 func validateDecisionMappings(s *styrav1beta1.SystemSpec, path *field.Path) field.ErrorList {
 	var errs field.ErrorList
 
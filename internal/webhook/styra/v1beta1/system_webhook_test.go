@@ -27,6 +27,7 @@ import (
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/validation/field"
 
+	configv2alpha2 "github.com/bankdata/styra-controller/api/config/v2alpha2"
 	"github.com/bankdata/styra-controller/api/styra/v1beta1"
 	"github.com/bankdata/styra-controller/pkg/ptr"
 )
@@ -74,14 +75,14 @@ var _ = ginkgo.Describe("System", ginkgo.Label("integration"), func() {
 			ginkgo.It("should not set a default part 1", func() {
 				ss.Spec.SourceControl = &v1beta1.SourceControl{
 					Origin: v1beta1.GitRepo{
-						Commit: "commitsha",
+						Commit: "f37cc9d87251921cbe49349235d9b5305c833769",
 					},
 				}
 
 				gomega.Ω(k8sClient.Update(ctx, ss)).To(gomega.Succeed())
 				gomega.Ω(ss.Spec.SourceControl).NotTo(gomega.BeNil())
 				gomega.Ω(ss.Spec.SourceControl.Origin.Reference).To(gomega.Equal(""))
-				gomega.Ω(ss.Spec.SourceControl.Origin.Commit).To(gomega.Equal("commitsha"))
+				gomega.Ω(ss.Spec.SourceControl.Origin.Commit).To(gomega.Equal("f37cc9d87251921cbe49349235d9b5305c833769"))
 			})
 
 			ginkgo.It("should not set a default part 2", func() {
@@ -100,7 +101,7 @@ var _ = ginkgo.Describe("System", ginkgo.Label("integration"), func() {
 			ginkgo.It("should not set a default part 3", func() {
 				ss.Spec.SourceControl = &v1beta1.SourceControl{
 					Origin: v1beta1.GitRepo{
-						Commit:    "commitsha",
+						Commit:    "f37cc9d87251921cbe49349235d9b5305c833769",
 						Reference: "reference",
 					},
 				}
@@ -108,7 +109,7 @@ var _ = ginkgo.Describe("System", ginkgo.Label("integration"), func() {
 				gomega.Ω(k8sClient.Update(ctx, ss)).To(gomega.Succeed())
 				gomega.Ω(ss.Spec.SourceControl).NotTo(gomega.BeNil())
 				gomega.Ω(ss.Spec.SourceControl.Origin.Reference).To(gomega.Equal("reference"))
-				gomega.Ω(ss.Spec.SourceControl.Origin.Commit).To(gomega.Equal("commitsha"))
+				gomega.Ω(ss.Spec.SourceControl.Origin.Commit).To(gomega.Equal("f37cc9d87251921cbe49349235d9b5305c833769"))
 			})
 		})
 	})
@@ -314,5 +315,191 @@ var _ = ginkgo.Describe("System", ginkgo.Label("integration"), func() {
 				}
 			})
 		})
+
+		ginkgo.Describe("SystemSpec.validateFiles", func() {
+			ginkgo.It("should validate that file paths are relative", func() {
+				ginkgo.By("providing relative paths we dont get an error")
+				ss.Spec.Files = map[string]string{"policy/main.rego": "package main"}
+				ss.Spec.FilesFrom = []v1beta1.FilesFromSource{{ConfigMapName: "policy", Path: "policy"}}
+				gomega.Ω(k8sClient.Update(ctx, ss)).To(gomega.Succeed())
+
+				ginkgo.By("providing paths outside the source we get errors")
+				ss.Spec.Files = map[string]string{"../main.rego": "package main"}
+				ss.Spec.FilesFrom = []v1beta1.FilesFromSource{{ConfigMapName: "policy", Path: "/policy"}}
+				err := k8sClient.Update(ctx, ss)
+				gomega.Ω(err).To(gomega.HaveOccurred())
+				var sErr *apierrors.StatusError
+				gomega.Ω(errors.As(err, &sErr)).To(gomega.BeTrue())
+				path := field.NewPath("spec")
+				msg := "must be a relative path within the source"
+				expErrs := field.ErrorList{
+					field.Invalid(path.Child("files").Key("../main.rego"), "../main.rego", msg),
+					field.Invalid(path.Child("filesFrom").Index(0).Child("path"), "/policy", msg),
+				}
+				causes := sErr.ErrStatus.Details.Causes
+				gomega.Ω(len(causes)).To(gomega.Equal(len(expErrs)))
+				for i, expErr := range expErrs {
+					gomega.Ω(string(causes[i].Type)).To(gomega.Equal(string(expErr.Type)))
+					gomega.Ω(causes[i].Message).To(gomega.Equal(expErr.ErrorBody()))
+					gomega.Ω(causes[i].Field).To(gomega.Equal(expErr.Field))
+				}
+			})
+		})
+
+		ginkgo.Describe("SystemSpec.validateRequirements", func() {
+			ginkgo.It("should validate that sources are unique", func() {
+				ginkgo.By("providing unique sources we dont get an error")
+				ss.Spec.Requirements = []v1beta1.Requirement{
+					{Source: "library1", Commit: "f37cc9d87251921cbe49349235d9b5305c833769"},
+					{Source: "library2"},
+				}
+				gomega.Ω(k8sClient.Update(ctx, ss)).To(gomega.Succeed())
+
+				ginkgo.By("having the same sources we get errors")
+				ss.Spec.Requirements = []v1beta1.Requirement{
+					{Source: "library1", Commit: "f37cc9d87251921cbe49349235d9b5305c833769"},
+					{Source: "library2"},
+					{Source: "library1"},
+				}
+				err := k8sClient.Update(ctx, ss)
+				gomega.Ω(err).To(gomega.HaveOccurred())
+				var sErr *apierrors.StatusError
+				gomega.Ω(errors.As(err, &sErr)).To(gomega.BeTrue())
+				path := field.NewPath("spec").Child("requirements")
+				expErrs := field.ErrorList{
+					field.Duplicate(path.Index(0).Child("source"), "library1"),
+					field.Duplicate(path.Index(2).Child("source"), "library1"),
+				}
+				causes := sErr.ErrStatus.Details.Causes
+				gomega.Ω(len(causes)).To(gomega.Equal(len(expErrs)))
+				for i, expErr := range expErrs {
+					gomega.Ω(string(causes[i].Type)).To(gomega.Equal(string(expErr.Type)))
+					gomega.Ω(causes[i].Message).To(gomega.Equal(expErr.ErrorBody()))
+					gomega.Ω(causes[i].Field).To(gomega.Equal(expErr.Field))
+				}
+			})
+
+			ginkgo.It("should validate that commits are full git commit SHAs", func() {
+				ginkgo.By("providing short and non-hex commits we get errors")
+				ss.Spec.Requirements = []v1beta1.Requirement{
+					{Source: "library1", Commit: "f37cc9d"},
+					{Source: "library2", Commit: "main"},
+					{Source: "library3", Commit: "f37cc9d87251921cbe49349235d9b5305c833769"},
+				}
+				err := k8sClient.Update(ctx, ss)
+				gomega.Ω(err).To(gomega.HaveOccurred())
+				var sErr *apierrors.StatusError
+				gomega.Ω(errors.As(err, &sErr)).To(gomega.BeTrue())
+				path := field.NewPath("spec").Child("requirements")
+				expErrs := field.ErrorList{
+					field.Invalid(path.Index(0).Child("commit"), "f37cc9d", "must be a full git commit SHA"),
+					field.Invalid(path.Index(1).Child("commit"), "main", "must be a full git commit SHA"),
+				}
+				causes := sErr.ErrStatus.Details.Causes
+				gomega.Ω(len(causes)).To(gomega.Equal(len(expErrs)))
+				for i, expErr := range expErrs {
+					gomega.Ω(string(causes[i].Type)).To(gomega.Equal(string(expErr.Type)))
+					gomega.Ω(causes[i].Message).To(gomega.Equal(expErr.ErrorBody()))
+					gomega.Ω(causes[i].Field).To(gomega.Equal(expErr.Field))
+				}
+			})
+		})
+
+		ginkgo.Describe("SystemSpec.validateSourceControl", func() {
+			ginkgo.It("should validate the git repository URL", func() {
+				ginkgo.By("providing https, ssh and scp-like URLs we dont get an error")
+				for _, url := range []string{
+					"https://github.com/org/repo.git",
+					"ssh://git@github.com/org/repo.git",
+					"git@github.com:org/repo.git",
+				} {
+					ss.Spec.SourceControl = &v1beta1.SourceControl{Origin: v1beta1.GitRepo{URL: url}}
+					gomega.Ω(k8sClient.Update(ctx, ss)).To(gomega.Succeed())
+				}
+
+				ginkgo.By("providing an invalid URL we get an error")
+				ss.Spec.SourceControl = &v1beta1.SourceControl{Origin: v1beta1.GitRepo{URL: "github.com/org/repo.git"}}
+				err := k8sClient.Update(ctx, ss)
+				gomega.Ω(err).To(gomega.HaveOccurred())
+				var sErr *apierrors.StatusError
+				gomega.Ω(errors.As(err, &sErr)).To(gomega.BeTrue())
+				expErr := field.Invalid(field.NewPath("spec", "sourceControl", "origin", "url"),
+					"github.com/org/repo.git", "must be a valid http(s) or ssh git repository URL")
+				causes := sErr.ErrStatus.Details.Causes
+				gomega.Ω(causes).To(gomega.HaveLen(1))
+				gomega.Ω(string(causes[0].Type)).To(gomega.Equal(string(expErr.Type)))
+				gomega.Ω(causes[0].Message).To(gomega.Equal(expErr.ErrorBody()))
+				gomega.Ω(causes[0].Field).To(gomega.Equal(expErr.Field))
+			})
+
+			ginkgo.It("should validate that the commit is a full git commit SHA", func() {
+				ss.Spec.SourceControl = &v1beta1.SourceControl{Origin: v1beta1.GitRepo{
+					URL:    "https://github.com/org/repo.git",
+					Commit: "f37cc9d",
+				}}
+				err := k8sClient.Update(ctx, ss)
+				gomega.Ω(err).To(gomega.HaveOccurred())
+				var sErr *apierrors.StatusError
+				gomega.Ω(errors.As(err, &sErr)).To(gomega.BeTrue())
+				expErr := field.Invalid(field.NewPath("spec", "sourceControl", "origin", "commit"),
+					"f37cc9d", "must be a full git commit SHA")
+				causes := sErr.ErrStatus.Details.Causes
+				gomega.Ω(causes).To(gomega.HaveLen(1))
+				gomega.Ω(string(causes[0].Type)).To(gomega.Equal(string(expErr.Type)))
+				gomega.Ω(causes[0].Message).To(gomega.Equal(expErr.ErrorBody()))
+				gomega.Ω(causes[0].Field).To(gomega.Equal(expErr.Field))
+			})
+		})
+	})
+})
+
+var _ = ginkgo.Describe("SystemCustomValidator.gitCredentialsWarnings", func() {
+	validator := &SystemCustomValidator{
+		Config: &configv2alpha2.ProjectConfig{
+			OPAControlPlaneConfig: &configv2alpha2.OPAControlPlaneConfig{
+				GitCredentials: []*configv2alpha2.GitCredentials{
+					{ID: "org", RepoPrefix: "https://github.com/org"},
+					{ID: "team", RepoPrefix: "https://github.com/org/team", Namespaces: []string{"team-*"}},
+					{ID: "bitbucket", RepoPrefix: "https://bitbucket.org/org", Namespaces: []string{"team-*"}},
+				},
+			},
+		},
+	}
+
+	newSystem := func(namespace, url string) *v1beta1.System {
+		return &v1beta1.System{
+			ObjectMeta: metav1.ObjectMeta{Name: "system", Namespace: namespace},
+			Spec: v1beta1.SystemSpec{
+				SourceControl: &v1beta1.SourceControl{Origin: v1beta1.GitRepo{URL: url}},
+			},
+		}
+	}
+
+	ginkgo.It("does not warn when git credentials can be resolved", func() {
+		gomega.Ω(validator.gitCredentialsWarnings(newSystem("default", "git@github.com:org/repo.git"))).To(gomega.BeEmpty())
+		gomega.Ω(validator.gitCredentialsWarnings(newSystem("team-a", "https://github.com/org/team/repo"))).
+			To(gomega.BeEmpty())
+	})
+
+	ginkgo.It("does not warn when falling back to git credentials the namespace may use", func() {
+		gomega.Ω(validator.gitCredentialsWarnings(newSystem("default", "https://github.com/org/team/repo"))).
+			To(gomega.BeEmpty())
+	})
+
+	ginkgo.It("does not warn when the System brings its own git credentials", func() {
+		system := newSystem("default", "https://gitlab.com/org/repo.git")
+		system.Spec.SourceControl.Origin.CredentialsSecretName = "git-credentials"
+		gomega.Ω(validator.gitCredentialsWarnings(system)).To(gomega.BeEmpty())
+	})
+
+	ginkgo.It("warns when no git credentials match the repository", func() {
+		gomega.Ω(validator.gitCredentialsWarnings(newSystem("default", "https://gitlab.com/org/repo.git"))).To(
+			gomega.ConsistOf(gomega.HavePrefix("spec.sourceControl.origin.url: no git credentials match")))
+	})
+
+	ginkgo.It("warns when the namespace may not use the matching git credentials", func() {
+		gomega.Ω(validator.gitCredentialsWarnings(newSystem("default", "https://bitbucket.org/org/repo"))).To(
+			gomega.ConsistOf(gomega.HaveSuffix("none of the git credentials matching repository " +
+				"https://bitbucket.org/org/repo are allowed in namespace default")))
 	})
 })
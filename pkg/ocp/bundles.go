@@ -18,9 +18,9 @@ package ocp
 
 import (
 	"context"
+	"encoding/json"
 	"io"
 	"net/http"
-	"path"
 
 	"github.com/bankdata/styra-controller/pkg/httperror"
 	"github.com/pkg/errors"
@@ -32,10 +32,10 @@ const (
 
 // ObjectStorage represents the object storage configuration for a bundle.
 type ObjectStorage struct {
-	AmazonS3 *AmazonS3 `json:"aws,omitempty" yaml:"aws,omitempty"`
-	// GCPCloudStorage   *GCPCloudStorage   `json:"gcp,omitempty" yaml:"gcp,omitempty"`
-	// AzureBlobStorage  *AzureBlobStorage  `json:"azure,omitempty" yaml:"azure,omitempty"`
-	// FileSystemStorage *FileSystemStorage `json:"filesystem,omitempty" yaml:"filesystem,omitempty"`
+	AmazonS3          *AmazonS3          `json:"aws,omitempty" yaml:"aws,omitempty"`
+	GCPCloudStorage   *GCPCloudStorage   `json:"gcp,omitempty" yaml:"gcp,omitempty"`
+	AzureBlobStorage  *AzureBlobStorage  `json:"azure,omitempty" yaml:"azure,omitempty"`
+	FileSystemStorage *FileSystemStorage `json:"filesystem,omitempty" yaml:"filesystem,omitempty"`
 }
 
 // AmazonS3 defines the configuration for a bundle stored in an Amazon S3 bucket.
@@ -77,6 +77,43 @@ type PutBundleRequest struct {
 	Requirements  []Requirement     `json:"requirements,omitempty" yaml:"requirements,omitempty"`
 	Revision      string            `json:"revision,omitempty" yaml:"revision,omitempty"`
 	ExcludedFiles []string          `json:"excluded_files,omitempty" yaml:"excluded_files,omitempty"`
+	// DeltaBundles requests that OCP builds delta bundles containing only the
+	// changes since the previous revision. The field is left out of the
+	// request when it is false, so OCP versions without delta bundle support
+	// are unaffected unless it is set.
+	DeltaBundles bool `json:"delta_bundles,omitempty" yaml:"delta_bundles,omitempty"`
+}
+
+// BundleConfig represents the configuration of a bundle in the OCP APIs.
+type BundleConfig struct {
+	Name          string            `json:"name,omitempty" yaml:"name,omitempty"`
+	Labels        map[string]string `json:"labels,omitempty" yaml:"labels,omitempty"`
+	ObjectStorage ObjectStorage     `json:"object_storage,omitempty" yaml:"object_storage,omitempty"`
+	Requirements  []Requirement     `json:"requirements,omitempty" yaml:"requirements,omitempty"`
+	Revision      string            `json:"revision,omitempty" yaml:"revision,omitempty"`
+	ExcludedFiles []string          `json:"excluded_files,omitempty" yaml:"excluded_files,omitempty"`
+	DeltaBundles  bool              `json:"delta_bundles,omitempty" yaml:"delta_bundles,omitempty"`
+}
+
+// GetBundleResponse is the response type for calls to the
+// GET /v1/bundles/{name} endpoint in the OCP API.
+type GetBundleResponse struct {
+	StatusCode int
+	Body       []byte
+	Bundle     *BundleConfig
+	Message    string
+}
+
+// ListBundlesResponse is the response type for calls to the
+// GET /v1/bundles endpoint in the OCP API.
+type ListBundlesResponse struct {
+	StatusCode int
+	Body       []byte
+	Bundles    []BundleConfig
+	// NextCursor is the cursor for the next page of bundles. It is empty when
+	// there are no more pages.
+	NextCursor string
+	Message    string
 }
 
 // PutBundleResponse is the response type for calls to the
@@ -87,9 +124,88 @@ type PutBundleResponse struct {
 	Message    string `json:"message"`
 }
 
+// GetBundle calls the GET /v1/bundles/{name} endpoint in the OCP API.
+func (c *Client) GetBundle(ctx context.Context, name string) (resp *GetBundleResponse, err error) {
+	res, err := c.request(ctx, http.MethodGet, resourceEndpoint(endpointV1Bundles, name), nil, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "GetBundle: could not call OCP")
+	}
+
+	// Close body and overwrite returned error if it is not set already.
+	defer func() {
+		closeErr := res.Body.Close()
+		if err == nil && closeErr != nil {
+			err = errors.Wrap(closeErr, "error closing response body")
+		}
+	}()
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, "GetBundle: could not read body")
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return nil, httperror.NewHTTPError(res.StatusCode, string(body))
+	}
+
+	var get getBody[BundleConfig]
+	if err := json.Unmarshal(body, &get); err != nil {
+		return nil, errors.Wrap(err, "GetBundle: could not unmarshal body")
+	}
+	if get.Result.Name == "" {
+		get.Result.Name = name
+	}
+
+	return &GetBundleResponse{
+		StatusCode: res.StatusCode,
+		Body:       body,
+		Message:    res.Status,
+		Bundle:     &get.Result,
+	}, nil
+}
+
+// ListBundles calls the GET /v1/bundles endpoint in the OCP API. The cursor
+// selects the page to return and should be empty for the first page.
+func (c *Client) ListBundles(ctx context.Context, cursor string) (resp *ListBundlesResponse, err error) {
+	res, err := c.request(ctx, http.MethodGet, withCursor(endpointV1Bundles, cursor), nil, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "ListBundles: could not call OCP")
+	}
+
+	// Close body and overwrite returned error if it is not set already.
+	defer func() {
+		closeErr := res.Body.Close()
+		if err == nil && closeErr != nil {
+			err = errors.Wrap(closeErr, "error closing response body")
+		}
+	}()
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, "ListBundles: could not read body")
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return nil, httperror.NewHTTPError(res.StatusCode, string(body))
+	}
+
+	var list listBody[BundleConfig]
+	if err := json.Unmarshal(body, &list); err != nil {
+		return nil, errors.Wrap(err, "ListBundles: could not unmarshal body")
+	}
+
+	return &ListBundlesResponse{
+		StatusCode: res.StatusCode,
+		Body:       body,
+		Message:    res.Status,
+		Bundles:    list.Result,
+		NextCursor: list.NextCursor,
+	}, nil
+}
+
 // PutBundle calls the PUT /v1/bundles/{name} endpoint in the OCP API.
 func (c *Client) PutBundle(ctx context.Context, bundle *PutBundleRequest) (err error) {
-	res, err := c.request(ctx, http.MethodPut, path.Join(endpointV1Bundles, bundle.Name), bundle, nil)
+	res, err := c.request(ctx, http.MethodPut, resourceEndpoint(endpointV1Bundles, bundle.Name), bundle, nil)
 	if err != nil {
 		return err
 	}
@@ -115,7 +231,7 @@ func (c *Client) PutBundle(ctx context.Context, bundle *PutBundleRequest) (err e
 
 // DeleteBundle calls the DELETE /v1/bundles/{name} endpoint in the OCP API.
 func (c *Client) DeleteBundle(ctx context.Context, name string) (err error) {
-	res, err := c.request(ctx, http.MethodDelete, path.Join(endpointV1Bundles, name), nil, nil)
+	res, err := c.request(ctx, http.MethodDelete, resourceEndpoint(endpointV1Bundles, name), nil, nil)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,182 @@
+/*
+Copyright (C) 2025 Bankdata (bankdata@bankdata.dk)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ocp_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+
+	ginkgo "github.com/onsi/ginkgo/v2"
+	gomega "github.com/onsi/gomega"
+
+	"github.com/bankdata/styra-controller/pkg/ocp"
+)
+
+var _ = ginkgo.DescribeTable("GetBundle",
+	func(res response, expected *ocp.BundleConfig, expectedStatus int) {
+		var requests []*http.Request
+		server := newServer(map[string]response{"/v1/bundles/test": res}, &requests)
+
+		resp, err := ocp.New(server.URL, "token").GetBundle(context.Background(), "test")
+
+		gomega.Expect(requests).To(gomega.HaveLen(1))
+		gomega.Expect(requests[0].Method).To(gomega.Equal(http.MethodGet))
+		gomega.Expect(requests[0].Header.Get("Authorization")).To(gomega.Equal("Bearer token"))
+
+		if expectedStatus != http.StatusOK {
+			gomega.Expect(errorStatus(err)).To(gomega.Equal(expectedStatus))
+			gomega.Expect(resp).To(gomega.BeNil())
+			return
+		}
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		gomega.Expect(resp.StatusCode).To(gomega.Equal(http.StatusOK))
+		gomega.Expect(resp.Bundle).To(gomega.Equal(expected))
+	},
+
+	ginkgo.Entry("returns the bundle",
+		response{status: http.StatusOK, body: fixture("get_bundle.json")},
+		&ocp.BundleConfig{
+			Name: "test",
+			Labels: map[string]string{
+				"app.kubernetes.io/managed-by":      "styra-controller",
+				"styra-controller/system-name":      "system",
+				"styra-controller/system-namespace": "default",
+			},
+			ObjectStorage: ocp.ObjectStorage{
+				AmazonS3: &ocp.AmazonS3{
+					Bucket:      "bundles",
+					Key:         "bundles/test/bundle.tar.gz",
+					Region:      "eu-west-1",
+					Credentials: "s3-credentials",
+				},
+			},
+			Requirements:  []ocp.Requirement{{Source: "test"}, {Source: "users"}},
+			Revision:      "abc",
+			ExcludedFiles: []string{"*_test.rego"},
+		},
+		http.StatusOK,
+	),
+
+	ginkgo.Entry("defaults the name to the requested name",
+		response{status: http.StatusOK, body: `{"result": {"revision": "abc"}}`},
+		&ocp.BundleConfig{Name: "test", Revision: "abc"},
+		http.StatusOK,
+	),
+
+	ginkgo.Entry("returns an http error when the bundle does not exist",
+		response{status: http.StatusNotFound, body: `{"code": "not_found"}`},
+		nil,
+		http.StatusNotFound,
+	),
+)
+
+var _ = ginkgo.DescribeTable("ListBundles",
+	func(cursor string, responses map[string]response, expected []string, expectedNextCursor string) {
+		var requests []*http.Request
+		server := newServer(responses, &requests)
+
+		resp, err := ocp.New(server.URL, "token").ListBundles(context.Background(), cursor)
+
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		gomega.Expect(requests).To(gomega.HaveLen(1))
+		gomega.Expect(requests[0].URL.Query().Get("cursor")).To(gomega.Equal(cursor))
+
+		names := make([]string, 0, len(resp.Bundles))
+		for _, bundle := range resp.Bundles {
+			names = append(names, bundle.Name)
+		}
+		gomega.Expect(names).To(gomega.Equal(expected))
+		gomega.Expect(resp.NextCursor).To(gomega.Equal(expectedNextCursor))
+	},
+
+	ginkgo.Entry("requests the first page without a cursor",
+		"",
+		map[string]response{
+			"/v1/bundles": {status: http.StatusOK, body: `{
+				"result": [{"name": "a"}, {"name": "b"}],
+				"next_cursor": "page-2"
+			}`},
+		},
+		[]string{"a", "b"},
+		"page-2",
+	),
+
+	ginkgo.Entry("requests the next page with the cursor",
+		"page-2",
+		map[string]response{
+			"/v1/bundles?cursor=page-2": {status: http.StatusOK, body: `{"result": [{"name": "c"}]}`},
+		},
+		[]string{"c"},
+		"",
+	),
+
+	ginkgo.Entry("encodes the cursor in the query",
+		"a b&c=d/e",
+		map[string]response{
+			"/v1/bundles?cursor=a+b%26c%3Dd%2Fe": {status: http.StatusOK, body: `{"result": []}`},
+		},
+		[]string{},
+		"",
+	),
+)
+
+var _ = ginkgo.Describe("ListBundles", func() {
+	ginkgo.It("returns an http error on unexpected status codes", func() {
+		var requests []*http.Request
+		server := newServer(map[string]response{
+			"/v1/bundles": {status: http.StatusInternalServerError, body: `{"code": "internal"}`},
+		}, &requests)
+
+		resp, err := ocp.New(server.URL, "token").ListBundles(context.Background(), "")
+
+		gomega.Expect(resp).To(gomega.BeNil())
+		gomega.Expect(errorStatus(err)).To(gomega.Equal(http.StatusInternalServerError))
+	})
+})
+
+var _ = ginkgo.DescribeTable("PutBundle",
+	func(deltaBundles bool, expectedBody string) {
+		var requests []*http.Request
+		server := newServer(map[string]response{
+			"/v1/bundles/test": {status: http.StatusOK, body: `{}`},
+		}, &requests)
+
+		err := ocp.New(server.URL, "token").PutBundle(context.Background(), &ocp.PutBundleRequest{
+			Name:         "test",
+			Requirements: []ocp.Requirement{{Source: "test"}},
+			DeltaBundles: deltaBundles,
+		})
+
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		gomega.Expect(requests).To(gomega.HaveLen(1))
+		gomega.Expect(requests[0].Method).To(gomega.Equal(http.MethodPut))
+		body, err := io.ReadAll(requests[0].Body)
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		gomega.Expect(body).To(gomega.MatchJSON(expectedBody))
+	},
+
+	ginkgo.Entry("leaves out delta_bundles when it is not requested",
+		false,
+		`{"object_storage": {}, "requirements": [{"source": "test", "git": {}}]}`,
+	),
+
+	ginkgo.Entry("sends delta_bundles when it is requested",
+		true,
+		`{"object_storage": {}, "requirements": [{"source": "test", "git": {}}], "delta_bundles": true}`,
+	),
+)
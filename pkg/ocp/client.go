@@ -23,6 +23,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/url"
 	"time"
 
 	"github.com/patrickmn/go-cache"
@@ -32,10 +33,17 @@ import (
 // ClientInterface defines the interface for the OCP client.
 type ClientInterface interface {
 	GetSource(ctx context.Context, id string) (*GetSourceResponse, error)
+	ListSources(ctx context.Context, cursor string) (*ListSourcesResponse, error)
 	PutSource(ctx context.Context, id string, request *PutSourceRequest) (*PutSourceResponse, error)
 	DeleteSource(ctx context.Context, id string) error
+	GetBundle(ctx context.Context, name string) (*GetBundleResponse, error)
+	ListBundles(ctx context.Context, cursor string) (*ListBundlesResponse, error)
 	PutBundle(ctx context.Context, bundle *PutBundleRequest) error
 	DeleteBundle(ctx context.Context, name string) error
+	GetSecret(ctx context.Context, id string) (*GetSecretResponse, error)
+	ListSecrets(ctx context.Context, cursor string) (*ListSecretsResponse, error)
+	PutSecret(ctx context.Context, id string, secret *Secret) error
+	DeleteSecret(ctx context.Context, id string) error
 }
 
 // Client is a client for the OCP APIs.
@@ -58,6 +66,33 @@ func New(url string, token string) ClientInterface {
 	}
 }
 
+// getBody is the body of responses from the endpoints in the OCP API which get
+// a single resource.
+type getBody[T any] struct {
+	Result T `json:"result"`
+}
+
+// listBody is the body of responses from the list endpoints in the OCP API.
+type listBody[T any] struct {
+	Result     []T    `json:"result"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// resourceEndpoint returns the endpoint of a single resource. The id is
+// escaped, so it is always a single path segment.
+func resourceEndpoint(endpoint string, id string) string {
+	return endpoint + "/" + url.PathEscape(id)
+}
+
+// withCursor adds the pagination cursor to a list endpoint. The endpoint is
+// returned unchanged for the first page.
+func withCursor(endpoint string, cursor string) string {
+	if cursor == "" {
+		return endpoint
+	}
+	return endpoint + "?" + url.Values{"cursor": []string{cursor}}.Encode()
+}
+
 // InvalidateCache invalidates the entire cache
 func (c *Client) InvalidateCache() {
 	c.Cache.Flush()
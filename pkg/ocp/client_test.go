@@ -0,0 +1,107 @@
+/*
+Copyright (C) 2025 Bankdata (bankdata@bankdata.dk)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ocp_test
+
+import (
+	"context"
+	"net/http"
+
+	ginkgo "github.com/onsi/ginkgo/v2"
+	gomega "github.com/onsi/gomega"
+
+	"github.com/bankdata/styra-controller/pkg/ocp"
+)
+
+var _ = ginkgo.DescribeTable("resource endpoints",
+	func(call func(context.Context, ocp.ClientInterface) error, expectedMethod string, expectedURI string) {
+		var requests []*http.Request
+		server := newServer(map[string]response{}, &requests)
+
+		_ = call(context.Background(), ocp.New(server.URL, "token"))
+
+		gomega.Expect(requests).To(gomega.HaveLen(1))
+		gomega.Expect(requests[0].Method).To(gomega.Equal(expectedMethod))
+		gomega.Expect(requests[0].URL.RequestURI()).To(gomega.Equal(expectedURI))
+	},
+
+	ginkgo.Entry("escapes the id when getting a source",
+		func(ctx context.Context, c ocp.ClientInterface) error {
+			_, err := c.GetSource(ctx, "a b/c")
+			return err
+		},
+		http.MethodGet, "/v1/sources/a%20b%2Fc",
+	),
+
+	ginkgo.Entry("escapes the id when putting a source",
+		func(ctx context.Context, c ocp.ClientInterface) error {
+			_, err := c.PutSource(ctx, "a b/c", &ocp.PutSourceRequest{})
+			return err
+		},
+		http.MethodPut, "/v1/sources/a%20b%2Fc",
+	),
+
+	ginkgo.Entry("escapes the id when deleting a source",
+		func(ctx context.Context, c ocp.ClientInterface) error {
+			return c.DeleteSource(ctx, "a b/c")
+		},
+		http.MethodDelete, "/v1/sources/a%20b%2Fc",
+	),
+
+	ginkgo.Entry("escapes the name when getting a bundle",
+		func(ctx context.Context, c ocp.ClientInterface) error {
+			_, err := c.GetBundle(ctx, "a b/c")
+			return err
+		},
+		http.MethodGet, "/v1/bundles/a%20b%2Fc",
+	),
+
+	ginkgo.Entry("escapes the name when putting a bundle",
+		func(ctx context.Context, c ocp.ClientInterface) error {
+			return c.PutBundle(ctx, &ocp.PutBundleRequest{Name: "a b/c"})
+		},
+		http.MethodPut, "/v1/bundles/a%20b%2Fc",
+	),
+
+	ginkgo.Entry("escapes the name when deleting a bundle",
+		func(ctx context.Context, c ocp.ClientInterface) error {
+			return c.DeleteBundle(ctx, "a b/c")
+		},
+		http.MethodDelete, "/v1/bundles/a%20b%2Fc",
+	),
+
+	ginkgo.Entry("escapes the id when getting a secret",
+		func(ctx context.Context, c ocp.ClientInterface) error {
+			_, err := c.GetSecret(ctx, "a b/c")
+			return err
+		},
+		http.MethodGet, "/v1/secrets/a%20b%2Fc",
+	),
+
+	ginkgo.Entry("escapes the id when putting a secret",
+		func(ctx context.Context, c ocp.ClientInterface) error {
+			return c.PutSecret(ctx, "a b/c", &ocp.Secret{Name: "a b/c"})
+		},
+		http.MethodPut, "/v1/secrets/a%20b%2Fc",
+	),
+
+	ginkgo.Entry("escapes the id when deleting a secret",
+		func(ctx context.Context, c ocp.ClientInterface) error {
+			return c.DeleteSecret(ctx, "a b/c")
+		},
+		http.MethodDelete, "/v1/secrets/a%20b%2Fc",
+	),
+)
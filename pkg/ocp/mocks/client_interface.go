@@ -32,6 +32,24 @@ func (_m *ClientInterface) DeleteBundle(ctx context.Context, name string) error
 	return r0
 }
 
+// DeleteSecret provides a mock function with given fields: ctx, id
+func (_m *ClientInterface) DeleteSecret(ctx context.Context, id string) error {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteSecret")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // DeleteSource provides a mock function with given fields: ctx, id
 func (_m *ClientInterface) DeleteSource(ctx context.Context, id string) error {
 	ret := _m.Called(ctx, id)
@@ -50,6 +68,66 @@ func (_m *ClientInterface) DeleteSource(ctx context.Context, id string) error {
 	return r0
 }
 
+// GetBundle provides a mock function with given fields: ctx, name
+func (_m *ClientInterface) GetBundle(ctx context.Context, name string) (*ocp.GetBundleResponse, error) {
+	ret := _m.Called(ctx, name)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetBundle")
+	}
+
+	var r0 *ocp.GetBundleResponse
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*ocp.GetBundleResponse, error)); ok {
+		return rf(ctx, name)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *ocp.GetBundleResponse); ok {
+		r0 = rf(ctx, name)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*ocp.GetBundleResponse)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, name)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetSecret provides a mock function with given fields: ctx, id
+func (_m *ClientInterface) GetSecret(ctx context.Context, id string) (*ocp.GetSecretResponse, error) {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetSecret")
+	}
+
+	var r0 *ocp.GetSecretResponse
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*ocp.GetSecretResponse, error)); ok {
+		return rf(ctx, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *ocp.GetSecretResponse); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*ocp.GetSecretResponse)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetSource provides a mock function with given fields: ctx, id
 func (_m *ClientInterface) GetSource(ctx context.Context, id string) (*ocp.GetSourceResponse, error) {
 	ret := _m.Called(ctx, id)
@@ -80,6 +158,96 @@ func (_m *ClientInterface) GetSource(ctx context.Context, id string) (*ocp.GetSo
 	return r0, r1
 }
 
+// ListBundles provides a mock function with given fields: ctx, cursor
+func (_m *ClientInterface) ListBundles(ctx context.Context, cursor string) (*ocp.ListBundlesResponse, error) {
+	ret := _m.Called(ctx, cursor)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListBundles")
+	}
+
+	var r0 *ocp.ListBundlesResponse
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*ocp.ListBundlesResponse, error)); ok {
+		return rf(ctx, cursor)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *ocp.ListBundlesResponse); ok {
+		r0 = rf(ctx, cursor)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*ocp.ListBundlesResponse)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, cursor)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListSecrets provides a mock function with given fields: ctx, cursor
+func (_m *ClientInterface) ListSecrets(ctx context.Context, cursor string) (*ocp.ListSecretsResponse, error) {
+	ret := _m.Called(ctx, cursor)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListSecrets")
+	}
+
+	var r0 *ocp.ListSecretsResponse
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*ocp.ListSecretsResponse, error)); ok {
+		return rf(ctx, cursor)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *ocp.ListSecretsResponse); ok {
+		r0 = rf(ctx, cursor)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*ocp.ListSecretsResponse)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, cursor)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListSources provides a mock function with given fields: ctx, cursor
+func (_m *ClientInterface) ListSources(ctx context.Context, cursor string) (*ocp.ListSourcesResponse, error) {
+	ret := _m.Called(ctx, cursor)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListSources")
+	}
+
+	var r0 *ocp.ListSourcesResponse
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*ocp.ListSourcesResponse, error)); ok {
+		return rf(ctx, cursor)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *ocp.ListSourcesResponse); ok {
+		r0 = rf(ctx, cursor)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*ocp.ListSourcesResponse)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, cursor)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // PutBundle provides a mock function with given fields: ctx, bundle
 func (_m *ClientInterface) PutBundle(ctx context.Context, bundle *ocp.PutBundleRequest) error {
 	ret := _m.Called(ctx, bundle)
@@ -98,6 +266,24 @@ func (_m *ClientInterface) PutBundle(ctx context.Context, bundle *ocp.PutBundleR
 	return r0
 }
 
+// PutSecret provides a mock function with given fields: ctx, id, secret
+func (_m *ClientInterface) PutSecret(ctx context.Context, id string, secret *ocp.Secret) error {
+	ret := _m.Called(ctx, id, secret)
+
+	if len(ret) == 0 {
+		panic("no return value specified for PutSecret")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, *ocp.Secret) error); ok {
+		r0 = rf(ctx, id, secret)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // PutSource provides a mock function with given fields: ctx, id, request
 func (_m *ClientInterface) PutSource(ctx context.Context, id string, request *ocp.PutSourceRequest) (*ocp.PutSourceResponse, error) {
 	ret := _m.Called(ctx, id, request)
@@ -18,6 +18,7 @@ package ocp
 
 import (
 	configv2alpha2 "github.com/bankdata/styra-controller/api/config/v2alpha2"
+	"github.com/bankdata/styra-controller/api/styra/v1beta1"
 )
 
 // OPAConfig stores the information going into the ConfigMap for the OPA
@@ -28,20 +29,32 @@ type OPAConfig struct {
 	Namespace            string
 	BundleResource       string
 	DecisionLogReporting configv2alpha2.DecisionLogReporting
+	// DecisionLogMaskDecision is the path of the decision used to mask
+	// decision log events. It is omitted from the config when empty.
+	DecisionLogMaskDecision string
+	// DiscoveryOverrides holds the System specific overrides of the status and
+	// distributed_tracing sections of the config.
+	DiscoveryOverrides *v1beta1.DiscoveryOverrides
+	// EnableDeltaBundles configures OPA to poll for bundle updates as
+	// configured for delta bundles.
+	EnableDeltaBundles bool
 }
 
 // OPAServiceConfig defines a services added to the OPAs' config files.
 type OPAServiceConfig struct {
 	Name                         string              `json:"name" yaml:"name"`
-	Credentials                  *ServiceCredentials `json:"credentials" yaml:"credentials"`
+	Credentials                  *ServiceCredentials `json:"credentials,omitempty" yaml:"credentials,omitempty"`
+	Headers                      map[string]string   `json:"headers,omitempty" yaml:"headers,omitempty"`
 	ResponseHeaderTimeoutSeconds int                 `json:"response_header_timeout_seconds,omitempty" yaml:"response_header_timeout_seconds,omitempty"` //nolint:lll
 	URL                          string              `json:"url" yaml:"url"`
 }
 
 // ServiceCredentials defines the structure for service credentials.
 type ServiceCredentials struct {
-	Bearer *Bearer    `json:"bearer,omitempty" yaml:"bearer,omitempty"`
-	S3     *S3Signing `json:"s3_signing,omitempty" yaml:"s3_signing,omitempty"`
+	Bearer               *Bearer               `json:"bearer,omitempty" yaml:"bearer,omitempty"`
+	S3                   *S3Signing            `json:"s3_signing,omitempty" yaml:"s3_signing,omitempty"`
+	GCPMetadata          *GCPMetadata          `json:"gcp_metadata,omitempty" yaml:"gcp_metadata,omitempty"`
+	AzureManagedIdentity *AzureManagedIdentity `json:"azure_managed_identity,omitempty" yaml:"azure_managed_identity,omitempty"` //nolint:lll
 }
 
 // S3Signing defines the structure for S3 signing configuration.
@@ -49,6 +62,19 @@ type S3Signing struct {
 	S3EnvironmentCredentials map[string]EmptyStruct `json:"environment_credentials" yaml:"environment_credentials"`
 }
 
+// GCPMetadata defines the structure for fetching OAuth2 access tokens from the
+// GCP metadata server.
+type GCPMetadata struct {
+	Scopes []string `json:"scopes,omitempty" yaml:"scopes,omitempty"`
+}
+
+// AzureManagedIdentity defines the structure for fetching access tokens for an
+// Azure managed identity.
+type AzureManagedIdentity struct {
+	Resource string `json:"resource,omitempty" yaml:"resource,omitempty"`
+	ClientID string `json:"client_id,omitempty" yaml:"client_id,omitempty"`
+}
+
 // EmptyStruct is an empty struct used for mapping empty values in S3EnvironmentCredentials
 type EmptyStruct struct{}
 
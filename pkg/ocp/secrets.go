@@ -0,0 +1,191 @@
+/*
+Copyright (C) 2025 Bankdata (bankdata@bankdata.dk)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ocp
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/bankdata/styra-controller/pkg/httperror"
+	"github.com/pkg/errors"
+)
+
+const (
+	endpointV1Secrets = "/v1/secrets"
+)
+
+// GetSecretResponse is the response type for calls to the
+// GET /v1/secrets/{id} endpoint in the OCP API.
+type GetSecretResponse struct {
+	StatusCode int
+	Body       []byte
+	Secret     *Secret
+	Message    string
+}
+
+// ListSecretsResponse is the response type for calls to the
+// GET /v1/secrets endpoint in the OCP API.
+type ListSecretsResponse struct {
+	StatusCode int
+	Body       []byte
+	Secrets    []Secret
+	// NextCursor is the cursor for the next page of secrets. It is empty when
+	// there are no more pages.
+	NextCursor string
+	Message    string
+}
+
+type secretListItem struct {
+	Name  string                 `json:"name"`
+	Value map[string]interface{} `json:"value,omitempty"`
+}
+
+// GetSecret calls the GET /v1/secrets/{id} endpoint in the OCP API.
+func (c *Client) GetSecret(ctx context.Context, id string) (resp *GetSecretResponse, err error) {
+	res, err := c.request(ctx, http.MethodGet, resourceEndpoint(endpointV1Secrets, id), nil, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "GetSecret: could not call OCP")
+	}
+
+	// Close body and overwrite returned error if it is not set already.
+	defer func() {
+		closeErr := res.Body.Close()
+		if err == nil && closeErr != nil {
+			err = errors.Wrap(closeErr, "error closing response body")
+		}
+	}()
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, "GetSecret: could not read body")
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return nil, httperror.NewHTTPError(res.StatusCode, string(body))
+	}
+
+	var value map[string]interface{}
+	if err := json.Unmarshal(body, &value); err != nil {
+		return nil, errors.Wrap(err, "GetSecret: could not unmarshal body")
+	}
+
+	return &GetSecretResponse{
+		StatusCode: res.StatusCode,
+		Body:       body,
+		Message:    res.Status,
+		Secret:     &Secret{Name: id, Value: value},
+	}, nil
+}
+
+// ListSecrets calls the GET /v1/secrets endpoint in the OCP API. The cursor
+// selects the page to return and should be empty for the first page.
+func (c *Client) ListSecrets(ctx context.Context, cursor string) (resp *ListSecretsResponse, err error) {
+	res, err := c.request(ctx, http.MethodGet, withCursor(endpointV1Secrets, cursor), nil, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "ListSecrets: could not call OCP")
+	}
+
+	// Close body and overwrite returned error if it is not set already.
+	defer func() {
+		closeErr := res.Body.Close()
+		if err == nil && closeErr != nil {
+			err = errors.Wrap(closeErr, "error closing response body")
+		}
+	}()
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, "ListSecrets: could not read body")
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return nil, httperror.NewHTTPError(res.StatusCode, string(body))
+	}
+
+	var list listBody[secretListItem]
+	if err := json.Unmarshal(body, &list); err != nil {
+		return nil, errors.Wrap(err, "ListSecrets: could not unmarshal body")
+	}
+
+	secrets := make([]Secret, 0, len(list.Result))
+	for _, item := range list.Result {
+		secrets = append(secrets, Secret{Name: item.Name, Value: item.Value})
+	}
+
+	return &ListSecretsResponse{
+		StatusCode: res.StatusCode,
+		Body:       body,
+		Message:    res.Status,
+		Secrets:    secrets,
+		NextCursor: list.NextCursor,
+	}, nil
+}
+
+// PutSecret calls the PUT /v1/secrets/{id} endpoint in the OCP API.
+func (c *Client) PutSecret(ctx context.Context, id string, secret *Secret) (err error) {
+	res, err := c.request(ctx, http.MethodPut, resourceEndpoint(endpointV1Secrets, id), secret.Value, nil)
+	if err != nil {
+		return errors.Wrap(err, "PutSecret: could not call OCP")
+	}
+
+	// Close body and overwrite returned error if it is not set already.
+	defer func() {
+		closeErr := res.Body.Close()
+		if err == nil && closeErr != nil {
+			err = errors.Wrap(closeErr, "error closing response body")
+		}
+	}()
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return errors.Wrap(err, "PutSecret: could not read body")
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return httperror.NewHTTPError(res.StatusCode, string(body))
+	}
+	return nil
+}
+
+// DeleteSecret calls the DELETE /v1/secrets/{id} endpoint in the OCP API.
+// Deleting a secret which does not exist is not an error.
+func (c *Client) DeleteSecret(ctx context.Context, id string) (err error) {
+	res, err := c.request(ctx, http.MethodDelete, resourceEndpoint(endpointV1Secrets, id), nil, nil)
+	if err != nil {
+		return errors.Wrap(err, "DeleteSecret: could not call OCP")
+	}
+
+	// Close body and overwrite returned error if it is not set already.
+	defer func() {
+		closeErr := res.Body.Close()
+		if err == nil && closeErr != nil {
+			err = errors.Wrap(closeErr, "error closing response body")
+		}
+	}()
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return errors.Wrap(err, "DeleteSecret: could not read body")
+	}
+
+	if res.StatusCode != http.StatusNotFound && res.StatusCode != http.StatusOK {
+		return httperror.NewHTTPError(res.StatusCode, string(body))
+	}
+	return nil
+}
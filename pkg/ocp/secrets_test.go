@@ -0,0 +1,131 @@
+/*
+Copyright (C) 2025 Bankdata (bankdata@bankdata.dk)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ocp_test
+
+import (
+	"context"
+	"net/http"
+
+	ginkgo "github.com/onsi/ginkgo/v2"
+	gomega "github.com/onsi/gomega"
+
+	"github.com/bankdata/styra-controller/pkg/ocp"
+)
+
+var _ = ginkgo.DescribeTable("GetSecret",
+	func(res response, expected *ocp.Secret, expectedStatus int) {
+		var requests []*http.Request
+		server := newServer(map[string]response{"/v1/secrets/test": res}, &requests)
+
+		resp, err := ocp.New(server.URL, "token").GetSecret(context.Background(), "test")
+
+		gomega.Expect(requests).To(gomega.HaveLen(1))
+		gomega.Expect(requests[0].Method).To(gomega.Equal(http.MethodGet))
+
+		if expectedStatus != http.StatusOK {
+			gomega.Expect(resp).To(gomega.BeNil())
+			gomega.Expect(errorStatus(err)).To(gomega.Equal(expectedStatus))
+			return
+		}
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		gomega.Expect(resp.Secret).To(gomega.Equal(expected))
+	},
+
+	ginkgo.Entry("returns the secret named by the id",
+		response{status: http.StatusOK, body: `{"type": "basic_auth", "username": "user"}`},
+		&ocp.Secret{Name: "test", Value: map[string]interface{}{"type": "basic_auth", "username": "user"}},
+		http.StatusOK,
+	),
+
+	ginkgo.Entry("returns an http error when the secret does not exist",
+		response{status: http.StatusNotFound, body: `{"code": "not_found"}`},
+		nil,
+		http.StatusNotFound,
+	),
+)
+
+var _ = ginkgo.DescribeTable("ListSecrets",
+	func(cursor string, responses map[string]response, expected []ocp.Secret, expectedNextCursor string) {
+		var requests []*http.Request
+		server := newServer(responses, &requests)
+
+		resp, err := ocp.New(server.URL, "token").ListSecrets(context.Background(), cursor)
+
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		gomega.Expect(requests).To(gomega.HaveLen(1))
+		gomega.Expect(requests[0].URL.Query().Get("cursor")).To(gomega.Equal(cursor))
+		gomega.Expect(resp.Secrets).To(gomega.Equal(expected))
+		gomega.Expect(resp.NextCursor).To(gomega.Equal(expectedNextCursor))
+	},
+
+	ginkgo.Entry("requests the first page without a cursor",
+		"",
+		map[string]response{
+			"/v1/secrets": {status: http.StatusOK, body: `{
+				"result": [{"name": "a", "value": {"type": "token_auth"}}, {"name": "b"}],
+				"next_cursor": "page-2"
+			}`},
+		},
+		[]ocp.Secret{
+			{Name: "a", Value: map[string]interface{}{"type": "token_auth"}},
+			{Name: "b"},
+		},
+		"page-2",
+	),
+
+	ginkgo.Entry("encodes the cursor in the query",
+		"a b&c=d/e",
+		map[string]response{
+			"/v1/secrets?cursor=a+b%26c%3Dd%2Fe": {status: http.StatusOK, body: `{"result": [{"name": "c"}]}`},
+		},
+		[]ocp.Secret{{Name: "c"}},
+		"",
+	),
+)
+
+var _ = ginkgo.DescribeTable("DeleteSecret",
+	func(res response, expectedStatus int) {
+		var requests []*http.Request
+		server := newServer(map[string]response{"/v1/secrets/test": res}, &requests)
+
+		err := ocp.New(server.URL, "token").DeleteSecret(context.Background(), "test")
+
+		gomega.Expect(requests).To(gomega.HaveLen(1))
+		gomega.Expect(requests[0].Method).To(gomega.Equal(http.MethodDelete))
+
+		if expectedStatus == 0 {
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			return
+		}
+		gomega.Expect(errorStatus(err)).To(gomega.Equal(expectedStatus))
+	},
+
+	ginkgo.Entry("deletes the secret",
+		response{status: http.StatusOK, body: `{}`},
+		0,
+	),
+
+	ginkgo.Entry("ignores secrets which do not exist",
+		response{status: http.StatusNotFound, body: `{"code": "not_found"}`},
+		0,
+	),
+
+	ginkgo.Entry("returns an http error on other status codes",
+		response{status: http.StatusInternalServerError, body: `{"code": "internal"}`},
+		http.StatusInternalServerError,
+	),
+)
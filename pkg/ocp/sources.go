@@ -19,7 +19,6 @@ package ocp
 import (
 	"context"
 	"encoding/json"
-	"fmt"
 	"io"
 	"net/http"
 
@@ -62,6 +61,18 @@ type GetSourceResponse struct {
 	Message    string
 }
 
+// ListSourcesResponse is the response type for calls to the
+// GET /v1/sources endpoint in the OCP API.
+type ListSourcesResponse struct {
+	StatusCode int
+	Body       []byte
+	Sources    []SourceConfig
+	// NextCursor is the cursor for the next page of sources. It is empty when
+	// there are no more pages.
+	NextCursor string
+	Message    string
+}
+
 // SourceConfig represents the configuration of a source in the OCP APIs.
 type SourceConfig struct {
 	Name          string            `json:"name,omitempty" yaml:"name,omitempty"`
@@ -97,10 +108,21 @@ type Datasource struct {
 }
 
 // SecretRef represents a reference to a secret for a datasource in a source.
+// It is represented by the name of the secret in the OCP API.
 type SecretRef struct {
 	Name string `json:"-" yaml:"-"`
 }
 
+// MarshalJSON marshals the SecretRef as the name of the secret.
+func (s SecretRef) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.Name)
+}
+
+// UnmarshalJSON unmarshals the SecretRef from the name of the secret.
+func (s *SecretRef) UnmarshalJSON(data []byte) error {
+	return json.Unmarshal(data, &s.Name)
+}
+
 // Secret represents a secret for a datasource in a source.
 type Secret struct {
 	Name  string                 `json:"-" yaml:"-"`
@@ -136,7 +158,7 @@ func ToRequirements(sources []string) []Requirement {
 
 // GetSource calls the GET /v1/sources/{id} endpoint in the OCP API.
 func (c *Client) GetSource(ctx context.Context, path string) (resp *GetSourceResponse, err error) {
-	res, err := c.request(ctx, http.MethodGet, fmt.Sprintf("%s/%s", endpointV1Sources, path), nil, nil)
+	res, err := c.request(ctx, http.MethodGet, resourceEndpoint(endpointV1Sources, path), nil, nil)
 	if err != nil {
 		return nil, errors.Wrap(err, "could not get source from OCP")
 	}
@@ -158,8 +180,8 @@ func (c *Client) GetSource(ctx context.Context, path string) (resp *GetSourceRes
 		return nil, httperror.NewHTTPError(res.StatusCode, string(body))
 	}
 
-	var sourceConfig SourceConfig
-	if err := json.Unmarshal(body, &sourceConfig); err != nil {
+	var get getBody[SourceConfig]
+	if err := json.Unmarshal(body, &get); err != nil {
 		return nil, errors.Wrap(err, "could not unmarshal GetSource body")
 	}
 
@@ -167,7 +189,46 @@ func (c *Client) GetSource(ctx context.Context, path string) (resp *GetSourceRes
 		StatusCode: res.StatusCode,
 		Body:       body,
 		Message:    res.Status,
-		Source:     &sourceConfig,
+		Source:     &get.Result,
+	}, nil
+}
+
+// ListSources calls the GET /v1/sources endpoint in the OCP API. The cursor
+// selects the page to return and should be empty for the first page.
+func (c *Client) ListSources(ctx context.Context, cursor string) (resp *ListSourcesResponse, err error) {
+	res, err := c.request(ctx, http.MethodGet, withCursor(endpointV1Sources, cursor), nil, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "ListSources: could not call OCP")
+	}
+
+	// Close body and overwrite returned error if it is not set already.
+	defer func() {
+		closeErr := res.Body.Close()
+		if err == nil && closeErr != nil {
+			err = errors.Wrap(closeErr, "error closing response body")
+		}
+	}()
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, "ListSources: could not read body")
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return nil, httperror.NewHTTPError(res.StatusCode, string(body))
+	}
+
+	var list listBody[SourceConfig]
+	if err := json.Unmarshal(body, &list); err != nil {
+		return nil, errors.Wrap(err, "ListSources: could not unmarshal body")
+	}
+
+	return &ListSourcesResponse{
+		StatusCode: res.StatusCode,
+		Body:       body,
+		Message:    res.Status,
+		Sources:    list.Result,
+		NextCursor: list.NextCursor,
 	}, nil
 }
 
@@ -177,7 +238,7 @@ func (c *Client) PutSource(
 	id string,
 	request *PutSourceRequest,
 ) (resp *PutSourceResponse, err error) {
-	res, err := c.request(ctx, http.MethodPut, fmt.Sprintf("%s/%s", endpointV1Sources, id), request, nil)
+	res, err := c.request(ctx, http.MethodPut, resourceEndpoint(endpointV1Sources, id), request, nil)
 	if err != nil {
 		return nil, errors.Wrap(err, "PutSource: could not call OCP")
 	}
@@ -208,7 +269,7 @@ func (c *Client) PutSource(
 
 // DeleteSource calls the DELETE /v1/sources/{name} endpoint in the OCP API.
 func (c *Client) DeleteSource(ctx context.Context, id string) (err error) {
-	res, err := c.request(ctx, http.MethodDelete, fmt.Sprintf("%s/%s", endpointV1Sources, id), nil, nil)
+	res, err := c.request(ctx, http.MethodDelete, resourceEndpoint(endpointV1Sources, id), nil, nil)
 	if err != nil {
 		return err
 	}
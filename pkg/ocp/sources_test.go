@@ -0,0 +1,159 @@
+/*
+Copyright (C) 2025 Bankdata (bankdata@bankdata.dk)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ocp_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	ginkgo "github.com/onsi/ginkgo/v2"
+	gomega "github.com/onsi/gomega"
+
+	"github.com/bankdata/styra-controller/pkg/ocp"
+)
+
+var _ = ginkgo.DescribeTable("GetSource",
+	func(res response, expected *ocp.SourceConfig, expectedStatus int) {
+		var requests []*http.Request
+		server := newServer(map[string]response{"/v1/sources/test": res}, &requests)
+
+		resp, err := ocp.New(server.URL, "token").GetSource(context.Background(), "test")
+
+		gomega.Expect(requests).To(gomega.HaveLen(1))
+		gomega.Expect(requests[0].Method).To(gomega.Equal(http.MethodGet))
+
+		if expectedStatus != http.StatusOK {
+			gomega.Expect(errorStatus(err)).To(gomega.Equal(expectedStatus))
+			gomega.Expect(resp).To(gomega.BeNil())
+			return
+		}
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		gomega.Expect(resp.Source).To(gomega.Equal(expected))
+	},
+
+	ginkgo.Entry("returns the source",
+		response{status: http.StatusOK, body: fixture("get_source.json")},
+		&ocp.SourceConfig{
+			Name: "test",
+			Git: ocp.GitConfig{
+				Repo:          "https://github.com/org/policies.git",
+				Reference:     "refs/heads/main",
+				Path:          "policies",
+				IncludedFiles: []string{"*.rego"},
+				CredentialID:  "test-git",
+			},
+			EmbeddedFiles: map[string]string{"system/log/mask.rego": "package system.log\n"},
+			Requirements:  []ocp.Requirement{{Source: "library"}},
+		},
+		http.StatusOK,
+	),
+
+	ginkgo.Entry("returns an http error when the source does not exist",
+		response{status: http.StatusNotFound, body: `{"code": "not_found"}`},
+		nil,
+		http.StatusNotFound,
+	),
+)
+
+var _ = ginkgo.DescribeTable("ListSources",
+	func(cursor string, responses map[string]response, expected []ocp.SourceConfig, expectedNextCursor string) {
+		var requests []*http.Request
+		server := newServer(responses, &requests)
+
+		resp, err := ocp.New(server.URL, "token").ListSources(context.Background(), cursor)
+
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		gomega.Expect(requests).To(gomega.HaveLen(1))
+		gomega.Expect(requests[0].Method).To(gomega.Equal(http.MethodGet))
+		gomega.Expect(requests[0].URL.Query().Get("cursor")).To(gomega.Equal(cursor))
+		gomega.Expect(resp.Sources).To(gomega.Equal(expected))
+		gomega.Expect(resp.NextCursor).To(gomega.Equal(expectedNextCursor))
+	},
+
+	ginkgo.Entry("requests the first page without a cursor",
+		"",
+		map[string]response{
+			"/v1/sources": {status: http.StatusOK, body: `{
+				"result": [
+					{"name": "a", "git": {"repo": "https://github.com/org/repo", "reference": "refs/heads/main"}},
+					{"name": "b", "requirements": [{"source": "a"}]}
+				],
+				"next_cursor": "page-2"
+			}`},
+		},
+		[]ocp.SourceConfig{
+			{Name: "a", Git: ocp.GitConfig{Repo: "https://github.com/org/repo", Reference: "refs/heads/main"}},
+			{Name: "b", Requirements: []ocp.Requirement{{Source: "a"}}},
+		},
+		"page-2",
+	),
+
+	ginkgo.Entry("requests the next page with the cursor",
+		"page-2",
+		map[string]response{
+			"/v1/sources?cursor=page-2": {status: http.StatusOK, body: `{"result": [{"name": "c"}]}`},
+		},
+		[]ocp.SourceConfig{{Name: "c"}},
+		"",
+	),
+
+	ginkgo.Entry("encodes the cursor in the query",
+		"a b&c=d/e",
+		map[string]response{
+			"/v1/sources?cursor=a+b%26c%3Dd%2Fe": {status: http.StatusOK, body: `{"result": []}`},
+		},
+		[]ocp.SourceConfig{},
+		"",
+	),
+)
+
+var _ = ginkgo.Describe("ListSources", func() {
+	ginkgo.It("returns an http error on unexpected status codes", func() {
+		var requests []*http.Request
+		server := newServer(map[string]response{
+			"/v1/sources": {status: http.StatusUnauthorized, body: `{"code": "unauthorized"}`},
+		}, &requests)
+
+		resp, err := ocp.New(server.URL, "token").ListSources(context.Background(), "")
+
+		gomega.Expect(resp).To(gomega.BeNil())
+		gomega.Expect(errorStatus(err)).To(gomega.Equal(http.StatusUnauthorized))
+	})
+})
+
+var _ = ginkgo.DescribeTable("SecretRef",
+	func(datasource ocp.Datasource, expectedJSON string) {
+		data, err := json.Marshal(datasource)
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		gomega.Expect(data).To(gomega.MatchJSON(expectedJSON))
+
+		var actual ocp.Datasource
+		gomega.Expect(json.Unmarshal(data, &actual)).To(gomega.Succeed())
+		gomega.Expect(actual).To(gomega.Equal(datasource))
+	},
+
+	ginkgo.Entry("is represented by the name of the secret",
+		ocp.Datasource{Name: "users", Type: "http", Credentials: &ocp.SecretRef{Name: "users-credentials"}},
+		`{"name": "users", "type": "http", "credentials": "users-credentials"}`,
+	),
+
+	ginkgo.Entry("is omitted when there are no credentials",
+		ocp.Datasource{Name: "users", Type: "http"},
+		`{"name": "users", "type": "http"}`,
+	),
+)
@@ -0,0 +1,84 @@
+/*
+Copyright (C) 2025 Bankdata (bankdata@bankdata.dk)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ocp_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	ginkgo "github.com/onsi/ginkgo/v2"
+	gomega "github.com/onsi/gomega"
+
+	"github.com/bankdata/styra-controller/pkg/httperror"
+)
+
+func TestOCP(t *testing.T) {
+	gomega.RegisterFailHandler(ginkgo.Fail)
+	ginkgo.RunSpecs(t, "pkg/ocp")
+}
+
+// response is a canned response from the fake OCP server.
+type response struct {
+	status int
+	body   string
+}
+
+// newServer starts a fake OCP server which answers each request URI with the
+// configured response. The requests it receives are appended to requests with
+// their bodies buffered, so they can be read after the response is sent.
+func newServer(responses map[string]response, requests *[]*http.Request) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer ginkgo.GinkgoRecover()
+		body, err := io.ReadAll(r.Body)
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		*requests = append(*requests, r)
+		res, ok := responses[r.URL.RequestURI()]
+		if !ok {
+			w.WriteHeader(http.StatusTeapot)
+			return
+		}
+		w.WriteHeader(res.status)
+		_, _ = w.Write([]byte(res.body))
+	}))
+	ginkgo.DeferCleanup(server.Close)
+	return server
+}
+
+// errorStatus returns the status code of the HTTPError wrapped in err.
+func errorStatus(err error) int {
+	var httpErr *httperror.HTTPError
+	gomega.ExpectWithOffset(1, errors.As(err, &httpErr)).To(gomega.BeTrue())
+	return httpErr.StatusCode
+}
+
+// fixture returns the content of a file in testdata. The files hold response
+// bodies in the shape returned by the OCP API. It panics as it is used while
+// the spec tree is built.
+func fixture(name string) string {
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		panic(err)
+	}
+	return string(data)
+}
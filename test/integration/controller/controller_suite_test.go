@@ -122,7 +122,7 @@ var _ = ginkgo.BeforeSuite(func() {
 				Token:   "ocp-token",
 				GitCredentials: []*configv2alpha2.GitCredentials{{
 					ID:         "github-credentials",
-					RepoPrefix: "https://github",
+					RepoPrefix: "https://github.com",
 				}},
 				DefaultRequirements: []string{"library1"},
 				BundleObjectStorage: &configv2alpha2.BundleObjectStorage{
@@ -176,6 +176,12 @@ var _ = ginkgo.BeforeSuite(func() {
 					Buckets: prometheus.DefBuckets,
 				}, []string{"result"},
 			),
+			DriftDetected: prometheus.NewCounterVec(
+				prometheus.CounterOpts{
+					Name: "controller_system_drift_detected_total",
+					Help: "Number of times a system source or bundle in OCP was found to have drifted",
+				}, []string{"system_name", "namespace", "resource"},
+			),
 		},
 	}
 
@@ -189,13 +195,38 @@ var _ = ginkgo.BeforeSuite(func() {
 				Token:   "ocp-token",
 				GitCredentials: []*configv2alpha2.GitCredentials{&configv2alpha2.GitCredentials{
 					ID:         "github-credentials",
-					RepoPrefix: "https://github",
+					RepoPrefix: "https://github.com",
 				}},
 			},
 		},
 		Client:        k8sClient,
+		Scheme:        k8sManager.GetScheme(),
 		OCP:           ocpClientMock,
 		WebhookClient: webhookMock,
+		Recorder:      k8sManager.GetEventRecorder("library-controller"),
+		Metrics: &styractrls.LibraryReconcilerMetrics{
+			ControllerLibraryStatusReady: prometheus.NewGaugeVec(
+				prometheus.GaugeOpts{
+					Name: "controller_library_status_ready",
+					Help: "Show if a library is in status ready",
+				},
+				[]string{"library_name", "library_id"},
+			),
+			ReconcileSegmentTime: prometheus.NewHistogramVec(
+				prometheus.HistogramOpts{
+					Name:    "controller_library_reconcile_segment_seconds",
+					Help:    "Time taken to perform one segment of reconciling a library",
+					Buckets: prometheus.DefBuckets,
+				}, []string{"segment"},
+			),
+			ReconcileTime: prometheus.NewHistogramVec(
+				prometheus.HistogramOpts{
+					Name:    "controller_library_reconcile_seconds",
+					Help:    "Time taken to reconcile a library",
+					Buckets: prometheus.DefBuckets,
+				}, []string{"result"},
+			),
+		},
 	}
 
 	err = libraryReconciler.SetupWithManager(k8sManager)
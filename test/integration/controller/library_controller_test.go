@@ -18,14 +18,18 @@ package styra
 
 import (
 	"context"
+	"net/http"
 
 	ginkgo "github.com/onsi/ginkgo/v2"
 	gomega "github.com/onsi/gomega"
 	"github.com/stretchr/testify/mock"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 
 	styrav1alpha1 "github.com/bankdata/styra-controller/api/styra/v1alpha1"
+	"github.com/bankdata/styra-controller/internal/finalizer"
+	"github.com/bankdata/styra-controller/pkg/httperror"
 	"github.com/bankdata/styra-controller/pkg/ocp"
 )
 
@@ -48,11 +52,29 @@ var _ = ginkgo.Describe("LibraryReconciler.Reconcile", ginkgo.Label("integration
 					},
 				},
 				Description: "description",
+				Datasources: []styrav1alpha1.LibraryDatasource{{
+					Path: "libraries/datasource",
+				}},
 			},
 		}
 		ctx := context.Background()
 		ginkgo.By("creating the Library")
 
+		// Called in createSourceIfNotExists for the library datasource
+		ocpClientMock.On("GetSource", mock.Anything, "libraries-datasource").Return(
+			nil, httperror.NewHTTPError(http.StatusNotFound, "404")).Once()
+		ocpClientMock.On("GetSource", mock.Anything, "libraries-datasource").Return(&ocp.GetSourceResponse{
+			StatusCode: http.StatusOK,
+			Source: &ocp.SourceConfig{
+				Name: "libraries-datasource",
+			},
+		}, nil)
+		ocpClientMock.On("PutSource", mock.Anything, "libraries-datasource", &ocp.PutSourceRequest{
+			Name: "libraries-datasource",
+		}).Return(&ocp.PutSourceResponse{}, nil).Once()
+		webhookMock.On("LibraryDatasourceChangedOCP", mock.Anything, mock.Anything, "libraries-datasource").
+			Return(nil).Once()
+
 		// Mock the OCP PutSource call for the library
 		ocpClientMock.On("PutSource", mock.Anything, key.Name, &ocp.PutSourceRequest{
 			Name: key.Name,
@@ -65,6 +87,7 @@ var _ = ginkgo.Describe("LibraryReconciler.Reconcile", ginkgo.Label("integration
 				IncludedFiles: []string{"*.rego"},
 				ExcludedFiles: []string{"*_test.rego"},
 			},
+			Requirements: []ocp.Requirement{{Source: "libraries-datasource"}},
 		}).Return(&ocp.PutSourceResponse{}, nil)
 
 		gomega.Ω(k8sClient.Create(ctx, toCreate)).
@@ -89,8 +112,38 @@ var _ = ginkgo.Describe("LibraryReconciler.Reconcile", ginkgo.Label("integration
 			return putSourceCalls == 1
 		}, timeout, interval).Should(gomega.BeTrue())
 		ocpClientMock.AssertExpectations(ginkgo.GinkgoT())
+		webhookMock.AssertExpectations(ginkgo.GinkgoT())
+
+		gomega.Eventually(func() bool {
+			var k8sLib styrav1alpha1.Library
+			if err := k8sClient.Get(ctx, key, &k8sLib); err != nil {
+				return false
+			}
+			return finalizer.IsSet(&k8sLib) &&
+				k8sLib.Status.Ready &&
+				k8sLib.Status.Phase == styrav1alpha1.LibraryPhaseCreated &&
+				k8sLib.Status.ObservedGeneration == k8sLib.Generation
+		}, timeout, interval).Should(gomega.BeTrue())
 
 		resetMock(&ocpClientMock.Mock)
 
+		ginkgo.By("Deleting the Library")
+
+		ocpClientMock.On("ListBundles", mock.Anything, "").Return(&ocp.ListBundlesResponse{}, nil)
+		ocpClientMock.On("DeleteSource", mock.Anything, key.Name).Return(nil)
+		ocpClientMock.On("DeleteSource", mock.Anything, "libraries-datasource").Return(nil)
+
+		gomega.Expect(k8sClient.Delete(ctx, toCreate)).To(gomega.Succeed())
+
+		gomega.Eventually(func() bool {
+			var k8sLib styrav1alpha1.Library
+			return k8serrors.IsNotFound(k8sClient.Get(ctx, key, &k8sLib))
+		}, timeout, interval).Should(gomega.BeTrue())
+
+		ocpClientMock.AssertCalled(ginkgo.GinkgoT(), "DeleteSource", mock.Anything, key.Name)
+		ocpClientMock.AssertCalled(ginkgo.GinkgoT(), "DeleteSource", mock.Anything, "libraries-datasource")
+
+		resetMock(&ocpClientMock.Mock)
+		resetMock(&webhookMock.Mock)
 	})
 })
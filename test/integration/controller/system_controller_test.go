@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"net/http"
 	"reflect"
+	"slices"
 
 	ginkgo "github.com/onsi/ginkgo/v2"
 	gomega "github.com/onsi/gomega"
@@ -109,6 +110,11 @@ var _ = ginkgo.Describe("SystemReconciler.Reconcile", ginkgo.Label("integration"
 		// Called in reconcileSystemBundle
 		ocpClientMock.On("PutBundle", mock.Anything, &ocp.PutBundleRequest{
 			Name: "default-ocp-system",
+			Labels: map[string]string{
+				"app.kubernetes.io/managed-by":      "styra-controller",
+				"styra-controller/system-namespace": "default",
+				"styra-controller/system-name":      "ocp-system",
+			},
 			ObjectStorage: ocp.ObjectStorage{
 				AmazonS3: &ocp.AmazonS3{
 					Bucket:      "test-bucket",
@@ -153,6 +159,13 @@ var _ = ginkgo.Describe("SystemReconciler.Reconcile", ginkgo.Label("integration"
 			systemStatusIsReady := fetched.Status.Ready
 			systemStatusPhaseIsCreated := fetched.Status.Phase == styrav1beta1.SystemPhaseCreated
 			systemStatusFailureMessageIsEmpty := fetched.Status.FailureMessage == ""
+			systemStatusHasOCPIdentifiers := fetched.Status.UniqueName == "default-ocp-system" &&
+				fetched.Status.BundleObjectKey == "bundles/default-ocp-system/bundle.tar.gz" &&
+				slices.Equal(fetched.Status.DatasourceSources, []string{"path-to-datasource"}) &&
+				fetched.Status.OPASecretName == fmt.Sprintf("%s-opa-secret", key.Name) &&
+				fetched.Status.OPAConfigMapName == fmt.Sprintf("%s-opa-config", key.Name) &&
+				fetched.Status.LastAppliedSpecHash != "" &&
+				fetched.Status.ObservedGeneration == fetched.Generation
 
 			conditionOPASecretUpdated := false
 			conditionOPAConfigMapUpdated := false
@@ -174,6 +187,7 @@ var _ = ginkgo.Describe("SystemReconciler.Reconcile", ginkgo.Label("integration"
 				systemStatusIsReady &&
 				systemStatusPhaseIsCreated &&
 				systemStatusFailureMessageIsEmpty &&
+				systemStatusHasOCPIdentifiers &&
 				conditionOPASecretUpdated &&
 				conditionOPAConfigMapUpdated &&
 				conditionOPAUpToDate
@@ -276,6 +290,7 @@ services:
 		ocpClientMock.On("DeleteBundle", mock.Anything, "default-ocp-system").Return(nil)
 		ocpClientMock.On("DeleteSource", mock.Anything, "default-ocp-system").Return(nil)
 		ocpClientMock.On("DeleteSource", mock.Anything, "path-to-datasource").Return(nil)
+		ocpClientMock.On("DeleteSecret", mock.Anything, "path-to-datasource-credentials").Return(nil)
 
 		gomega.Expect(k8sClient.Delete(ctx, toCreate)).To(gomega.Succeed())
 